@@ -0,0 +1,63 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+)
+
+// nodes, returns every node in the tree rooted at node.
+func nodes(node Node) []Node {
+	var all []Node
+	Inspect(node, func(n Node) bool {
+		if n != nil {
+			all = append(all, n)
+		}
+		return true
+	})
+	return all
+}
+
+func Test_Clone(t *testing.T) {
+	original := program()
+	original.Statements = append(original.Statements, Let(Identifier("m"), Macro(Block(), Identifier("x"))))
+	original.Statements[0].(*LetNode).Identifier.Span = token.Span{Start: 4, End: 7}
+
+	clone := Clone(original)
+	assert.True(t, Equal(original, clone))
+
+	// No node of the clone is shared with the original.
+	seen := map[Node]bool{}
+	for _, n := range nodes(original) {
+		seen[n] = true
+	}
+	for _, n := range nodes(clone) {
+		assert.False(t, seen[n], "%T is shared", n)
+	}
+}
+
+func Test_Clone_NoAliasing(t *testing.T) {
+	original := program()
+	clone := Clone(original)
+
+	let := clone.Statements[0].(*LetNode)
+	fn := let.Value.(*FunctionNode)
+	call := clone.Statements[1].(*ExpressionStatementNode).Expression.(*IfNode).Consequence.Statements[0].(*ExpressionStatementNode).Expression.(*CallNode)
+
+	let.Identifier.Value = "sum"
+	fn.Parameters[0] = Identifier("a")
+	fn.Parameters = append(fn.Parameters, Identifier("z"))
+	fn.Body.Statements[0] = ExpressionStatement(Integer(0))
+	call.Arguments[1] = Integer(3)
+	clone.Statements = clone.Statements[:1]
+
+	assert.True(t, Equal(program(), original))
+	assert.False(t, Equal(original, clone))
+}
+
+func Test_Clone_Nil(t *testing.T) {
+	var block *BlockNode
+	assert.Nil(t, Clone(block))
+	assert.Nil(t, Clone[Node](nil))
+}
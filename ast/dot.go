@@ -0,0 +1,49 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DotWriter, writes a tree as a Graphviz DOT graph.
+// Each node is labelled with its kind and values, each edge with the field holding the child.
+type DotWriter struct {
+	writer io.Writer
+	count  int
+}
+
+func NewDotWriter(w io.Writer) *DotWriter {
+	return &DotWriter{writer: w, count: 0}
+}
+
+func (w *DotWriter) Write(node Node) {
+	fmt.Fprint(w.writer, "digraph ast {\n")
+	fmt.Fprint(w.writer, "\tnode [shape=box, fontname=\"monospace\"];\n")
+	w.Node(node)
+	fmt.Fprint(w.writer, "}\n")
+}
+
+// Node, writes node and its descendants, returning the id node was given.
+func (w *DotWriter) Node(node Node) string {
+	id := fmt.Sprintf("n%d", w.count)
+	w.count++
+
+	scalars, children := fields(node)
+	label := strings.Join(append([]string{kind(node)}, scalars...), " ")
+	fmt.Fprintf(w.writer, "\t%s [label=%s];\n", id, strconv.Quote(label))
+
+	for _, f := range children {
+		for i, n := range f.nodes {
+			edge := f.name
+			if f.list {
+				edge = fmt.Sprintf("%s[%d]", f.name, i)
+			}
+
+			fmt.Fprintf(w.writer, "\t%s -> %s [label=%s];\n", id, w.Node(n), strconv.Quote(edge))
+		}
+	}
+
+	return id
+}
@@ -0,0 +1,105 @@
+package ast
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -a * b + c(d)
+func precedence() Node {
+	return ExpressionStatement(
+		Infix(
+			Infix(Prefix("-", Identifier("a")), "*", Identifier("b")),
+			"+",
+			Call(Identifier("c"), Identifier("d")),
+		),
+	)
+}
+
+func Test_SExprWriter(t *testing.T) {
+	var buf bytes.Buffer
+	NewSExprWriter(&buf).Write(precedence())
+
+	expected := `(expression-statement
+  (infix +
+    (infix *
+      (prefix -
+        (identifier a))
+      (identifier b))
+    (call
+      (identifier c)
+      (arguments
+        (identifier d)))))
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_SExprWriter_Lists(t *testing.T) {
+	given := Root(
+		Let(Identifier("f"), Function(Block(Return(True())))),
+		ExpressionStatement(If(Identifier("x"), Block(), nil)),
+	)
+
+	var buf bytes.Buffer
+	NewSExprWriter(&buf).Write(given)
+
+	expected := `(root
+  (let
+    (identifier f)
+    (function
+      (parameters)
+      (block
+        (return
+          (boolean true)))))
+  (expression-statement
+    (if
+      (identifier x)
+      (block))))
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_DotWriter(t *testing.T) {
+	var buf bytes.Buffer
+	NewDotWriter(&buf).Write(precedence())
+
+	expected := `digraph ast {
+	node [shape=box, fontname="monospace"];
+	n0 [label="expression-statement"];
+	n1 [label="infix +"];
+	n2 [label="infix *"];
+	n3 [label="prefix -"];
+	n4 [label="identifier a"];
+	n3 -> n4 [label="right"];
+	n2 -> n3 [label="left"];
+	n5 [label="identifier b"];
+	n2 -> n5 [label="right"];
+	n1 -> n2 [label="left"];
+	n6 [label="call"];
+	n7 [label="identifier c"];
+	n6 -> n7 [label="function"];
+	n8 [label="identifier d"];
+	n6 -> n8 [label="arguments[0]"];
+	n1 -> n6 [label="right"];
+	n0 -> n1 [label="expression"];
+}
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_SExprWriter_Annotations(t *testing.T) {
+	given := &FunctionNode{
+		Parameters:     []*IdentifierNode{Identifier("x")},
+		ParameterTypes: []*TypeNode{FunctionType(Type("int"), Type("bool"))},
+		ReturnType:     Type("int"),
+		Body:           Block(),
+	}
+
+	var buf bytes.Buffer
+	NewSExprWriter(&buf).Write(given)
+
+	expected := "(function\n  (parameters\n    (identifier x))\n  (parameterTypes\n    (type\n      (parameters\n        (type bool))\n      (type int)))\n  (type int)\n  (block))\n"
+	assert.Equal(t, expected, buf.String())
+}
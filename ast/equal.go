@@ -0,0 +1,173 @@
+package ast
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+)
+
+type compareOptions struct {
+	ignoreSpans bool
+}
+
+// Option, changes how trees are compared and hashed.
+type Option func(*compareOptions)
+
+// IgnoreSpans, compares trees by structure alone, ignoring where their nodes came from.
+func IgnoreSpans() Option {
+	return func(o *compareOptions) {
+		o.ignoreSpans = true
+	}
+}
+
+func newCompareOptions(opts []Option) compareOptions {
+	var o compareOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Equal, returns true if the trees rooted at a and b have the same structure and values.
+// An empty list of nodes is equal to a missing one.
+func Equal(a, b Node, opts ...Option) bool {
+	return Diff(a, b, opts...) == ""
+}
+
+// Diff, describes the first difference between the trees rooted at expected and actual,
+// or returns an empty string if they are equal. The description starts with the path
+// to the difference, for example:
+//
+//	Statements[2].Value.Right.Operator: expected "+", got "-"
+func Diff(expected, actual Node, opts ...Option) string {
+	o := newCompareOptions(opts)
+	return o.diff("", reflect.ValueOf(&expected).Elem(), reflect.ValueOf(&actual).Elem())
+}
+
+func (o compareOptions) diff(path string, expected, actual reflect.Value) string {
+	at := func(format string, args ...any) string {
+		if path == "" {
+			return fmt.Sprintf(format, args...)
+		}
+		return path + ": " + fmt.Sprintf(format, args...)
+	}
+
+	switch expected.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		switch {
+		case expected.IsNil() && actual.IsNil():
+			return ""
+		case expected.IsNil():
+			return at("expected nil, got %s", describe(actual))
+		case actual.IsNil():
+			return at("expected %s, got nil", describe(expected))
+		}
+
+		if expected.Kind() == reflect.Interface {
+			expected, actual = expected.Elem(), actual.Elem()
+			if expected.Type() != actual.Type() {
+				return at("expected %s, got %s", expected.Type(), actual.Type())
+			}
+			return o.diff(path, expected, actual)
+		}
+
+		return o.diff(path, expected.Elem(), actual.Elem())
+	case reflect.Struct:
+		if expected.Type() == spanType && o.ignoreSpans {
+			return ""
+		}
+
+		for i := 0; i < expected.NumField(); i++ {
+			name := expected.Type().Field(i).Name
+			if path != "" {
+				name = path + "." + name
+			}
+
+			if d := o.diff(name, expected.Field(i), actual.Field(i)); d != "" {
+				return d
+			}
+		}
+
+		return ""
+	case reflect.Slice:
+		for i := 0; i < min(expected.Len(), actual.Len()); i++ {
+			if d := o.diff(fmt.Sprintf("%s[%d]", path, i), expected.Index(i), actual.Index(i)); d != "" {
+				return d
+			}
+		}
+
+		if expected.Len() != actual.Len() {
+			return at("expected %d items, got %d", expected.Len(), actual.Len())
+		}
+
+		return ""
+	default:
+		if expected.Interface() != actual.Interface() {
+			return at("expected %s, got %s", describe(expected), describe(actual))
+		}
+
+		return ""
+	}
+}
+
+func describe(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return "nil"
+		}
+		if v.Kind() == reflect.Interface {
+			return v.Elem().Type().String()
+		}
+		return v.Type().String()
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
+
+// Hash, returns a hash of the structure and values of the tree rooted at node.
+// Trees that are Equal under the same options have the same hash.
+func Hash(node Node, opts ...Option) uint64 {
+	o := newCompareOptions(opts)
+
+	h := fnv.New64a()
+	o.hash(h, reflect.ValueOf(&node).Elem())
+	return h.Sum64()
+}
+
+func (o compareOptions) hash(w io.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			io.WriteString(w, "nil;")
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			io.WriteString(w, v.Elem().Type().String())
+		}
+		o.hash(w, v.Elem())
+	case reflect.Struct:
+		if v.Type() == spanType && o.ignoreSpans {
+			return
+		}
+		io.WriteString(w, "{")
+		for i := 0; i < v.NumField(); i++ {
+			o.hash(w, v.Field(i))
+		}
+		io.WriteString(w, "}")
+	case reflect.Slice:
+		binary.Write(w, binary.LittleEndian, int64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			o.hash(w, v.Index(i))
+		}
+	case reflect.String:
+		binary.Write(w, binary.LittleEndian, int64(v.Len()))
+		io.WriteString(w, v.String())
+	default:
+		fmt.Fprintf(w, "%v;", v.Interface())
+	}
+}
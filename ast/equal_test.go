@@ -0,0 +1,115 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func spanned(node *IntegerNode, start, end int) *IntegerNode {
+	node.Span = token.Span{Start: start, End: end}
+	return node
+}
+
+func Test_Equal(t *testing.T) {
+	assert.True(t, Equal(program(), program()))
+	assert.True(t, Equal(nil, nil))
+	assert.False(t, Equal(Integer(1), nil))
+	assert.False(t, Equal(Integer(1), Integer(2)))
+	assert.False(t, Equal(Integer(1), Identifier("1")))
+
+	// Empty and missing lists are the same.
+	assert.True(t, Equal(Block(), &BlockNode{Statements: []Statement{}}))
+}
+
+func Test_Equal_IgnoreSpans(t *testing.T) {
+	a := Infix(spanned(Integer(1), 0, 1), "+", Integer(2))
+	b := Infix(spanned(Integer(1), 4, 5), "+", Integer(2))
+
+	assert.False(t, Equal(a, b))
+	assert.True(t, Equal(a, b, IgnoreSpans()))
+}
+
+func Test_Diff(t *testing.T) {
+	given := func() *RootNode {
+		return Root(
+			ExpressionStatement(Integer(1)),
+			Let(Identifier("x"), Infix(Integer(1), "+", Integer(2))),
+			Let(Identifier("y"), Prefix("-", Infix(Integer(1), "+", Integer(2)))),
+		)
+	}
+
+	testcases := []struct {
+		name     string
+		modify   func(*RootNode)
+		expected string
+	}{
+		{
+			name:     "equal",
+			modify:   func(*RootNode) {},
+			expected: "",
+		},
+		{
+			name: "operator",
+			modify: func(r *RootNode) {
+				r.Statements[2].(*LetNode).Value.(*PrefixNode).Right.(*InfixNode).Operator = "-"
+			},
+			expected: `Statements[2].Value.Right.Operator: expected "+", got "-"`,
+		},
+		{
+			name: "value",
+			modify: func(r *RootNode) {
+				r.Statements[1].(*LetNode).Value.(*InfixNode).Right = Integer(3)
+			},
+			expected: "Statements[1].Value.Right.Value: expected 2, got 3",
+		},
+		{
+			name: "node type",
+			modify: func(r *RootNode) {
+				r.Statements[0] = Return(Integer(1))
+			},
+			expected: "Statements[0]: expected *ast.ExpressionStatementNode, got *ast.ReturnNode",
+		},
+		{
+			name: "missing node",
+			modify: func(r *RootNode) {
+				r.Statements[1].(*LetNode).Value = nil
+			},
+			expected: "Statements[1].Value: expected *ast.InfixNode, got nil",
+		},
+		{
+			name: "length",
+			modify: func(r *RootNode) {
+				r.Statements = r.Statements[:2]
+			},
+			expected: "Statements: expected 3 items, got 2",
+		},
+		{
+			name: "span",
+			modify: func(r *RootNode) {
+				r.Statements[1].(*LetNode).Identifier.Span.End = 1
+			},
+			expected: "Statements[1].Identifier.Span.End: expected 0, got 1",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := given()
+			tc.modify(actual)
+			assert.Equal(t, tc.expected, Diff(given(), actual))
+		})
+	}
+}
+
+func Test_Hash(t *testing.T) {
+	a := Infix(spanned(Integer(1), 0, 1), "+", Integer(2))
+	b := Infix(spanned(Integer(1), 4, 5), "+", Integer(2))
+
+	assert.Equal(t, Hash(program()), Hash(program()))
+	assert.NotEqual(t, Hash(a), Hash(b))
+	assert.Equal(t, Hash(a, IgnoreSpans()), Hash(b, IgnoreSpans()))
+	assert.NotEqual(t, Hash(Infix(Integer(1), "+", Integer(2))), Hash(Infix(Integer(2), "+", Integer(1))))
+	assert.NotEqual(t, Hash(Identifier("ab")), Hash(Identifier("a")))
+}
@@ -16,6 +16,34 @@ func If(condition Expression, consequence *BlockNode, alternative *BlockNode) *I
 	return &IfNode{Condition: condition, Consequence: consequence, Alternative: alternative}
 }
 
+func Conditional(condition, consequence, alternative Expression) *ConditionalNode {
+	return &ConditionalNode{Condition: condition, Consequence: consequence, Alternative: alternative}
+}
+
+func While(condition Expression, body *BlockNode) *WhileNode {
+	return &WhileNode{Condition: condition, Body: body}
+}
+
+func For(value *IdentifierNode, iterable Expression, body *BlockNode) *ForNode {
+	return &ForNode{Value: value, Iterable: iterable, Body: body}
+}
+
+func Assign(name *IdentifierNode, operator string, value Expression) *AssignNode {
+	return &AssignNode{Name: name, Operator: operator, Value: value}
+}
+
+func Range(start, end Expression) *RangeNode {
+	return &RangeNode{Start: start, End: end}
+}
+
+func Break() *BreakNode {
+	return &BreakNode{}
+}
+
+func Continue() *ContinueNode {
+	return &ContinueNode{}
+}
+
 func Block(statements ...Statement) *BlockNode {
 	return &BlockNode{Statements: statements}
 }
@@ -24,6 +52,10 @@ func Function(body *BlockNode, parameters ...*IdentifierNode) *FunctionNode {
 	return &FunctionNode{Parameters: parameters, Body: body}
 }
 
+func Macro(body *BlockNode, parameters ...*IdentifierNode) *MacroNode {
+	return &MacroNode{Parameters: parameters, Body: body}
+}
+
 func Call(function Expression, arguments ...Expression) *CallNode {
 	return &CallNode{Function: function, Arguments: arguments}
 }
@@ -45,6 +77,10 @@ func False() *BooleanNode {
 	return &BooleanNode{Value: false}
 }
 
+func Null() *NullNode {
+	return &NullNode{}
+}
+
 func ExpressionStatement(expression Expression) *ExpressionStatementNode {
 	return &ExpressionStatementNode{Expression: expression}
 }
@@ -56,3 +92,11 @@ func Prefix(operator string, right Expression) *PrefixNode {
 func Infix(left Expression, operator string, right Expression) *InfixNode {
 	return &InfixNode{Left: left, Operator: operator, Right: right}
 }
+
+func Type(name string) *TypeNode {
+	return &TypeNode{Name: name}
+}
+
+func FunctionType(returns *TypeNode, parameters ...*TypeNode) *TypeNode {
+	return &TypeNode{Parameters: parameters, Return: returns}
+}
@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/maybe-joe/monkey/token"
+)
+
+var (
+	spanType     = reflect.TypeOf(token.Span{})
+	typeNodeType = reflect.TypeOf(&TypeNode{})
+)
+
+// field, the child nodes held by one field of a node.
+type field struct {
+	// name, the name of the field in camel case.
+	name string
+	// list, true if the field holds a list of nodes rather than a single node.
+	list  bool
+	nodes []Node
+}
+
+// fields, returns the scalar values and the node fields of node in declaration order,
+// leaving out spans and missing lists of annotations. Used by the writers that show
+// the structure of a tree.
+func fields(node Node) (scalars []string, children []field) {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, nil
+	}
+
+	v = v.Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+
+		switch {
+		case f.Type == spanType:
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem() == typeNodeType && fv.IsNil():
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Implements(nodeType):
+			c := field{name: jsonField(f.Name), list: true}
+			for j := 0; j < fv.Len(); j++ {
+				if n := fv.Index(j).Interface().(Node); !isNil(n) {
+					c.nodes = append(c.nodes, n)
+				}
+			}
+			children = append(children, c)
+		case f.Type.Implements(nodeType):
+			c := field{name: jsonField(f.Name)}
+			if n, ok := fv.Interface().(Node); ok && !isNil(n) {
+				c.nodes = []Node{n}
+			}
+			children = append(children, c)
+		default:
+			if s := fmt.Sprint(fv.Interface()); s != "" {
+				scalars = append(scalars, s)
+			}
+		}
+	}
+
+	return scalars, children
+}
+
+// kind, the name of a node in kebab case, ExpressionStatementNode becomes expression-statement.
+func kind(node Node) string {
+	name := jsonName(reflect.TypeOf(node).Elem())
+
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
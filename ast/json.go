@@ -0,0 +1,209 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// jsonNodes, every node type that can be encoded, keyed by the name used in the "type" field.
+var jsonNodes = map[string]reflect.Type{}
+
+func init() {
+	for _, n := range []Node{
+		&RootNode{}, &LetNode{}, &ReturnNode{}, &IfNode{}, &BlockNode{}, &FunctionNode{},
+		&MacroNode{}, &IdentifierNode{}, &IntegerNode{}, &BooleanNode{}, &CallNode{},
+		&ExpressionStatementNode{}, &PrefixNode{}, &InfixNode{}, &TypeNode{}, &WhileNode{},
+		&BreakNode{}, &ContinueNode{}, &ForNode{}, &RangeNode{}, &AssignNode{},
+		&NullNode{}, &ConditionalNode{},
+	} {
+		t := reflect.TypeOf(n).Elem()
+		jsonNodes[jsonName(t)] = t
+	}
+}
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// MarshalJSON, encodes the tree rooted at node as JSON.
+//
+// Each node becomes an object with a "type" field naming the node, such as "Let" for
+// a LetNode, followed by its fields in camel case. Missing children are encoded as null.
+//
+//	{"type":"Prefix","span":{"start":0,"end":2},"operator":"-","right":{"type":"Integer",...}}
+func MarshalJSON(node Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, reflect.ValueOf(node)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON, decodes a tree encoded by MarshalJSON.
+func UnmarshalJSON(data []byte) (Node, error) {
+	v, err := decodeJSON(data, nodeType)
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.IsValid() || v.IsNil() {
+		return nil, nil
+	}
+
+	return v.Interface().(Node), nil
+}
+
+func encodeJSON(buf *bytes.Buffer, v reflect.Value) error {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+	}
+
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type().Implements(nodeType) && v.Kind() == reflect.Pointer:
+		t := v.Elem().Type()
+		if _, ok := jsonNodes[jsonName(t)]; !ok {
+			return fmt.Errorf("ast: cannot encode %s as json", t)
+		}
+
+		fmt.Fprintf(buf, `{"type":%q`, jsonName(t))
+		for i := 0; i < t.NumField(); i++ {
+			fmt.Fprintf(buf, ",%q:", jsonField(t.Field(i).Name))
+			if err := encodeJSON(buf, v.Elem().Field(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case v.Kind() == reflect.Slice && v.Type().Elem().Implements(nodeType):
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+
+		buf.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := encodeJSON(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	default:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+
+	return nil
+}
+
+// decodeJSON, decodes data into a value of type t, which is either a node,
+// an interface satisfied by nodes or a slice of either.
+func decodeJSON(data []byte, t reflect.Type) (reflect.Value, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return reflect.Zero(t), nil
+	}
+
+	if t.Kind() == reflect.Slice {
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return reflect.Value{}, err
+		}
+
+		slice := reflect.MakeSlice(t, 0, len(items))
+		for _, item := range items {
+			v, err := decodeJSON(item, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice = reflect.Append(slice, v)
+		}
+
+		return slice, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return reflect.Value{}, err
+	}
+
+	var name string
+	if err := json.Unmarshal(fields["type"], &name); err != nil {
+		return reflect.Value{}, fmt.Errorf("ast: node without a type: %s", data)
+	}
+
+	nt, ok := jsonNodes[name]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("ast: unknown node type %q", name)
+	}
+
+	node := reflect.New(nt)
+	if !node.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("ast: %s node where %s expected", name, describeJSON(t))
+	}
+
+	for i := 0; i < nt.NumField(); i++ {
+		field := nt.Field(i)
+
+		raw, ok := fields[jsonField(field.Name)]
+		if !ok {
+			continue
+		}
+
+		if isNodeField(field.Type) {
+			v, err := decodeJSON(raw, field.Type)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			node.Elem().Field(i).Set(v)
+			continue
+		}
+
+		if err := json.Unmarshal(raw, node.Elem().Field(i).Addr().Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("ast: %s.%s: %w", name, jsonField(field.Name), err)
+		}
+	}
+
+	return node, nil
+}
+
+func isNodeField(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	return t.Implements(nodeType)
+}
+
+func describeJSON(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		return jsonName(t.Elem())
+	}
+
+	return strings.ToLower(t.Name())
+}
+
+// jsonName, the name of a node type in the "type" field, LetNode becomes Let.
+func jsonName(t reflect.Type) string {
+	return strings.TrimSuffix(t.Name(), "Node")
+}
+
+// jsonField, the name of a field, Consequence becomes consequence.
+func jsonField(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
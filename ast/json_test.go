@@ -0,0 +1,82 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MarshalJSON(t *testing.T) {
+	given := Prefix("-", Integer(5))
+	given.Span = token.Span{Start: 0, End: 2}
+
+	actual, err := MarshalJSON(ExpressionStatement(given))
+	require.NoError(t, err)
+
+	expected := `{"type":"ExpressionStatement","expression":{"type":"Prefix","operator":"-",` +
+		`"right":{"type":"Integer","value":5,"span":{"start":0,"end":0}},"span":{"start":0,"end":2}},` +
+		`"span":{"start":0,"end":0}}`
+	assert.JSONEq(t, expected, string(actual))
+}
+
+func Test_MarshalJSON_Missing(t *testing.T) {
+	actual, err := MarshalJSON(If(True(), Block(), nil))
+	require.NoError(t, err)
+
+	expected := `{"type":"If","condition":{"type":"Boolean","value":true,"span":{"start":0,"end":0}},` +
+		`"consequence":{"type":"Block","statements":null,"span":{"start":0,"end":0}},"alternative":null,` +
+		`"span":{"start":0,"end":0}}`
+	assert.JSONEq(t, expected, string(actual))
+}
+
+func Test_JSON_RoundTrip(t *testing.T) {
+	given := program()
+	given.Statements = append(given.Statements,
+		Let(Identifier("m"), Macro(Block(), Identifier("x"))),
+		&LetNode{
+			Identifier: Identifier("f"),
+			Annotation: FunctionType(Type("int"), Type("int")),
+			Value: &FunctionNode{
+				Parameters:     []*IdentifierNode{Identifier("x")},
+				ParameterTypes: []*TypeNode{Type("int")},
+				Body:           Block(),
+			},
+		},
+		While(True(), Block(Continue(), Break())),
+		&ForNode{Value: Identifier("x"), Iterable: Range(Integer(0), Integer(3)), Body: Block()},
+		ExpressionStatement(Assign(Identifier("x"), "+=", Integer(1))),
+		ExpressionStatement(Infix(Null(), "??", Integer(1))),
+		ExpressionStatement(Conditional(True(), Integer(1), Integer(2))),
+	)
+	given.Span = token.Span{Start: 1, End: 99}
+
+	data, err := MarshalJSON(given)
+	require.NoError(t, err)
+
+	actual, err := UnmarshalJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, given, actual)
+}
+
+func Test_UnmarshalJSON_Errors(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected string
+	}{
+		{name: "unknown type", given: `{"type":"Loop"}`, expected: `ast: unknown node type "Loop"`},
+		{name: "missing type", given: `{"value":1}`, expected: `ast: node without a type: {"value":1}`},
+		{name: "statement as expression", given: `{"type":"Prefix","operator":"-","right":{"type":"Return"}}`, expected: "ast: Return node where expression expected"},
+		{name: "wrong node", given: `{"type":"Let","identifier":{"type":"Integer","value":1}}`, expected: "ast: Integer node where Identifier expected"},
+		{name: "bad field", given: `{"type":"Integer","value":"one"}`, expected: "ast: Integer.value: json: cannot unmarshal string into Go value of type int64"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalJSON([]byte(tc.given))
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
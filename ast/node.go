@@ -1,7 +1,11 @@
 package ast
 
+import "github.com/maybe-joe/monkey/token"
+
 type Node interface {
 	node()
+	// Location, the span of code the node was parsed from.
+	Location() token.Span
 }
 
 type Statement interface {
@@ -16,99 +20,243 @@ type Expression interface {
 
 type RootNode struct {
 	Statements []Statement
+	Span       token.Span
 }
 
-func (RootNode) node()      {}
-func (RootNode) statement() {}
+func (RootNode) node()                  {}
+func (n RootNode) Location() token.Span { return n.Span }
+func (RootNode) statement()             {}
 
 type LetNode struct {
 	Identifier *IdentifierNode
+	// Annotation, the type after the name, nil if there is none.
+	Annotation *TypeNode
 	Value      Expression
+	Span       token.Span
 }
 
-func (LetNode) node()      {}
-func (LetNode) statement() {}
+func (LetNode) node()                  {}
+func (n LetNode) Location() token.Span { return n.Span }
+func (LetNode) statement()             {}
 
 type ReturnNode struct {
 	Value Expression
+	Span  token.Span
 }
 
-func (ReturnNode) node()      {}
-func (ReturnNode) statement() {}
+func (ReturnNode) node()                  {}
+func (n ReturnNode) Location() token.Span { return n.Span }
+func (ReturnNode) statement()             {}
 
 type IfNode struct {
 	Condition   Expression
 	Consequence *BlockNode
 	Alternative *BlockNode
+	Span        token.Span
+}
+
+func (IfNode) node()                  {}
+func (n IfNode) Location() token.Span { return n.Span }
+func (IfNode) expression()            {}
+
+type WhileNode struct {
+	Condition Expression
+	Body      *BlockNode
+	Span      token.Span
+}
+
+func (WhileNode) node()                  {}
+func (n WhileNode) Location() token.Span { return n.Span }
+func (WhileNode) statement()             {}
+
+// ForNode, runs the body once for each item of Iterable, with Value bound to the item.
+type ForNode struct {
+	Value    *IdentifierNode
+	Iterable Expression
+	Body     *BlockNode
+	Span     token.Span
+}
+
+func (ForNode) node()                  {}
+func (n ForNode) Location() token.Span { return n.Span }
+func (ForNode) statement()             {}
+
+// BreakNode, leaves the innermost loop.
+type BreakNode struct {
+	Span token.Span
 }
 
-func (IfNode) node()       {}
-func (IfNode) expression() {}
+func (BreakNode) node()                  {}
+func (n BreakNode) Location() token.Span { return n.Span }
+func (BreakNode) statement()             {}
+
+// ContinueNode, skips to the next iteration of the innermost loop.
+type ContinueNode struct {
+	Span token.Span
+}
+
+func (ContinueNode) node()                  {}
+func (n ContinueNode) Location() token.Span { return n.Span }
+func (ContinueNode) statement()             {}
 
 type BlockNode struct {
 	Statements []Statement
+	Span       token.Span
 }
 
-func (BlockNode) node()      {}
-func (BlockNode) statement() {}
+func (BlockNode) node()                  {}
+func (n BlockNode) Location() token.Span { return n.Span }
+func (BlockNode) statement()             {}
 
 type FunctionNode struct {
+	Parameters []*IdentifierNode
+	// ParameterTypes, the annotation of each parameter, nil for those without one.
+	// The list itself is nil when no parameter has an annotation.
+	ParameterTypes []*TypeNode
+	// ReturnType, the annotation after the parameters, nil if there is none.
+	ReturnType *TypeNode
+	Body       *BlockNode
+	Span       token.Span
+}
+
+func (FunctionNode) node()                  {}
+func (n FunctionNode) Location() token.Span { return n.Span }
+func (FunctionNode) expression()            {}
+
+type MacroNode struct {
 	Parameters []*IdentifierNode
 	Body       *BlockNode
+	Span       token.Span
 }
 
-func (FunctionNode) node()       {}
-func (FunctionNode) expression() {}
+func (MacroNode) node()                  {}
+func (n MacroNode) Location() token.Span { return n.Span }
+func (MacroNode) expression()            {}
 
 type IdentifierNode struct {
 	Value string
+	Span  token.Span
 }
 
-func (IdentifierNode) node()       {}
-func (IdentifierNode) expression() {}
+func (IdentifierNode) node()                  {}
+func (n IdentifierNode) Location() token.Span { return n.Span }
+func (IdentifierNode) expression()            {}
 
 type IntegerNode struct {
 	Value int64
+	Span  token.Span
 }
 
-func (IntegerNode) node()       {}
-func (IntegerNode) expression() {}
+func (IntegerNode) node()                  {}
+func (n IntegerNode) Location() token.Span { return n.Span }
+func (IntegerNode) expression()            {}
 
 type BooleanNode struct {
 	Value bool
+	Span  token.Span
 }
 
-func (BooleanNode) node()       {}
-func (BooleanNode) expression() {}
+func (BooleanNode) node()                  {}
+func (n BooleanNode) Location() token.Span { return n.Span }
+func (BooleanNode) expression()            {}
+
+// NullNode, the null literal, the value of an expression that has none.
+type NullNode struct {
+	Span token.Span
+}
+
+func (NullNode) node()                  {}
+func (n NullNode) Location() token.Span { return n.Span }
+func (NullNode) expression()            {}
 
 type CallNode struct {
 	Function  Expression
 	Arguments []Expression
+	Span      token.Span
 }
 
-func (CallNode) node()       {}
-func (CallNode) expression() {}
+func (CallNode) node()                  {}
+func (n CallNode) Location() token.Span { return n.Span }
+func (CallNode) expression()            {}
 
 type ExpressionStatementNode struct {
 	Expression Expression
+	Span       token.Span
 }
 
-func (ExpressionStatementNode) node()      {}
-func (ExpressionStatementNode) statement() {}
+func (ExpressionStatementNode) node()                  {}
+func (n ExpressionStatementNode) Location() token.Span { return n.Span }
+func (ExpressionStatementNode) statement()             {}
 
 type PrefixNode struct {
 	Operator string
 	Right    Expression
+	Span     token.Span
 }
 
-func (PrefixNode) node()       {}
-func (PrefixNode) expression() {}
+func (PrefixNode) node()                  {}
+func (n PrefixNode) Location() token.Span { return n.Span }
+func (PrefixNode) expression()            {}
 
 type InfixNode struct {
 	Left     Expression
 	Operator string
 	Right    Expression
+	Span     token.Span
+}
+
+func (InfixNode) node()                  {}
+func (n InfixNode) Location() token.Span { return n.Span }
+func (InfixNode) expression()            {}
+
+// ConditionalNode, the expression cond ? a : b, the value of Consequence when the
+// Condition is truthy and of Alternative otherwise.
+type ConditionalNode struct {
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+	Span        token.Span
+}
+
+func (ConditionalNode) node()                  {}
+func (n ConditionalNode) Location() token.Span { return n.Span }
+func (ConditionalNode) expression()            {}
+
+// AssignNode, rebinds the name to Value, or for a compound assignment such as +=
+// to the result of the operator applied to its current value and Value.
+type AssignNode struct {
+	Name     *IdentifierNode
+	Operator string
+	Value    Expression
+	Span     token.Span
+}
+
+func (AssignNode) node()                  {}
+func (n AssignNode) Location() token.Span { return n.Span }
+func (AssignNode) expression()            {}
+
+// RangeNode, the integers from Start up to but not including End.
+type RangeNode struct {
+	Start Expression
+	End   Expression
+	Span  token.Span
 }
 
-func (InfixNode) node()       {}
-func (InfixNode) expression() {}
+func (RangeNode) node()                  {}
+func (n RangeNode) Location() token.Span { return n.Span }
+func (RangeNode) expression()            {}
+
+// TypeNode, a type annotation. Either a name such as int, or a function type such as
+// fn(int, bool) -> int, which has a Return and no Name.
+type TypeNode struct {
+	Name       string
+	Parameters []*TypeNode
+	Return     *TypeNode
+	Span       token.Span
+}
+
+func (TypeNode) node()                  {}
+func (n TypeNode) Location() token.Span { return n.Span }
+
+// IsFunction, returns true if the annotation is a function type.
+func (n TypeNode) IsFunction() bool { return n.Return != nil }
@@ -0,0 +1,73 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SExprWriter, writes a tree as indented S-expressions with one node per line.
+//
+//	(infix +
+//	  (prefix -
+//	    (identifier a))
+//	  (identifier b))
+//
+// A list of nodes, such as the parameters of a function, is wrapped in an expression
+// named after its field unless it holds every child of the node. Missing nodes are left out.
+type SExprWriter struct {
+	writer io.Writer
+	indent int
+}
+
+func NewSExprWriter(w io.Writer) *SExprWriter {
+	return &SExprWriter{writer: w, indent: 0}
+}
+
+func (w *SExprWriter) Write(node Node) {
+	w.Node(node)
+	fmt.Fprint(w.writer, "\n")
+}
+
+func (w *SExprWriter) Node(node Node) {
+	scalars, children := fields(node)
+
+	fmt.Fprintf(w.writer, "(%s", kind(node))
+	for _, s := range scalars {
+		fmt.Fprintf(w.writer, " %s", s)
+	}
+
+	w.indent++
+	for _, f := range children {
+		if f.list && len(children) > 1 {
+			w.List(f)
+			continue
+		}
+
+		for _, n := range f.nodes {
+			w.Newline()
+			w.Node(n)
+		}
+	}
+	w.indent--
+
+	fmt.Fprint(w.writer, ")")
+}
+
+func (w *SExprWriter) List(f field) {
+	w.Newline()
+	fmt.Fprintf(w.writer, "(%s", f.name)
+
+	w.indent++
+	for _, n := range f.nodes {
+		w.Newline()
+		w.Node(n)
+	}
+	w.indent--
+
+	fmt.Fprint(w.writer, ")")
+}
+
+func (w *SExprWriter) Newline() {
+	fmt.Fprintf(w.writer, "\n%s", strings.Repeat("  ", w.indent))
+}
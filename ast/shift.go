@@ -0,0 +1,26 @@
+package ast
+
+import (
+	"reflect"
+
+	"github.com/maybe-joe/monkey/token"
+)
+
+// Shift, moves the spans of node and every node below it by delta bytes, as when
+// code before them has been edited. The nodes are changed in place.
+func Shift(node Node, delta int) {
+	if delta == 0 || isNil(node) {
+		return
+	}
+
+	Inspect(node, func(n Node) bool {
+		if n == nil {
+			return false
+		}
+
+		if span := reflect.ValueOf(n).Elem().FieldByName("Span"); span.IsValid() && span.Type() == spanType {
+			span.Set(reflect.ValueOf(span.Interface().(token.Span).Shift(delta)))
+		}
+		return true
+	})
+}
@@ -0,0 +1,22 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Shift(t *testing.T) {
+	let := Let(Identifier("a"), Infix(Integer(1), "+", Integer(2)))
+	let.Span = token.Span{Start: 0, End: 14}
+	let.Identifier.Span = token.Span{Start: 4, End: 5}
+
+	Shift(let, 10)
+
+	for _, n := range nodes(let) {
+		assert.GreaterOrEqual(t, n.Location().Start, 10, "%T", n)
+	}
+	assert.Equal(t, token.Span{Start: 10, End: 24}, let.Span)
+	assert.Equal(t, token.Span{Start: 14, End: 15}, let.Identifier.Span)
+}
@@ -0,0 +1,244 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Visitor, Visit is called for each node found by Walk.
+// If the visitor returned is not nil, Walk visits each child of node with it,
+// followed by a call of Visit(nil).
+type Visitor interface {
+	Visit(node Node) Visitor
+}
+
+// Walk, traverses the tree rooted at node depth first, in the order the code was written.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	for _, child := range Children(node) {
+		Walk(v, child)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect, traverses the tree rooted at node calling f for each node, followed by f(nil).
+// The children of a node are skipped when f returns false.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Children, returns the direct children of node in the order the code was written.
+// Missing children, such as an if without an else, are left out.
+func Children(node Node) []Node {
+	var children []Node
+
+	add := func(nodes ...Node) {
+		for _, n := range nodes {
+			if !isNil(n) {
+				children = append(children, n)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case *RootNode:
+		for _, stmt := range n.Statements {
+			add(stmt)
+		}
+	case *BlockNode:
+		for _, stmt := range n.Statements {
+			add(stmt)
+		}
+	case *LetNode:
+		add(n.Identifier, n.Annotation, n.Value)
+	case *ReturnNode:
+		add(n.Value)
+	case *ExpressionStatementNode:
+		add(n.Expression)
+	case *IfNode:
+		add(n.Condition, n.Consequence, n.Alternative)
+	case *WhileNode:
+		add(n.Condition, n.Body)
+	case *ForNode:
+		add(n.Value, n.Iterable, n.Body)
+	case *FunctionNode:
+		for i, param := range n.Parameters {
+			add(param)
+			if i < len(n.ParameterTypes) {
+				add(n.ParameterTypes[i])
+			}
+		}
+		add(n.ReturnType, n.Body)
+	case *MacroNode:
+		for _, param := range n.Parameters {
+			add(param)
+		}
+		add(n.Body)
+	case *CallNode:
+		add(n.Function)
+		for _, arg := range n.Arguments {
+			add(arg)
+		}
+	case *PrefixNode:
+		add(n.Right)
+	case *InfixNode:
+		add(n.Left, n.Right)
+	case *ConditionalNode:
+		add(n.Condition, n.Consequence, n.Alternative)
+	case *AssignNode:
+		add(n.Name, n.Value)
+	case *RangeNode:
+		add(n.Start, n.End)
+	case *TypeNode:
+		for _, param := range n.Parameters {
+			add(param)
+		}
+		add(n.Return)
+	}
+
+	return children
+}
+
+// ModifierFunc, returns the node to put in place of the one given.
+// Returning the node unchanged leaves the tree as it is, returning nil removes it.
+type ModifierFunc func(Node) Node
+
+// Modify, rewrites the tree rooted at node in place, children before their parents,
+// and returns the result of calling modifier on node itself.
+//
+// Nodes removed from statement, argument or parameter lists are dropped from the list,
+// elsewhere the field is set to nil. Replacing a node with one that cannot go in its
+// place, such as a statement where an expression is expected, panics.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case *RootNode:
+		n.Statements = modifyAll(n.Statements, modifier)
+	case *BlockNode:
+		n.Statements = modifyAll(n.Statements, modifier)
+	case *LetNode:
+		n.Identifier = modifyOne[*IdentifierNode](n, n.Identifier, modifier)
+		n.Annotation = modifyOne[*TypeNode](n, n.Annotation, modifier)
+		n.Value = modifyOne[Expression](n, n.Value, modifier)
+	case *ReturnNode:
+		n.Value = modifyOne[Expression](n, n.Value, modifier)
+	case *ExpressionStatementNode:
+		n.Expression = modifyOne[Expression](n, n.Expression, modifier)
+	case *IfNode:
+		n.Condition = modifyOne[Expression](n, n.Condition, modifier)
+		n.Consequence = modifyOne[*BlockNode](n, n.Consequence, modifier)
+		n.Alternative = modifyOne[*BlockNode](n, n.Alternative, modifier)
+	case *WhileNode:
+		n.Condition = modifyOne[Expression](n, n.Condition, modifier)
+		n.Body = modifyOne[*BlockNode](n, n.Body, modifier)
+	case *ForNode:
+		n.Value = modifyOne[*IdentifierNode](n, n.Value, modifier)
+		n.Iterable = modifyOne[Expression](n, n.Iterable, modifier)
+		n.Body = modifyOne[*BlockNode](n, n.Body, modifier)
+	case *FunctionNode:
+		n.Parameters = modifyAll(n.Parameters, modifier)
+		// Parameter types line up with the parameters, so removing one leaves a nil in its place.
+		for i, typ := range n.ParameterTypes {
+			n.ParameterTypes[i] = modifyOne[*TypeNode](n, typ, modifier)
+		}
+		n.ReturnType = modifyOne[*TypeNode](n, n.ReturnType, modifier)
+		n.Body = modifyOne[*BlockNode](n, n.Body, modifier)
+	case *MacroNode:
+		n.Parameters = modifyAll(n.Parameters, modifier)
+		n.Body = modifyOne[*BlockNode](n, n.Body, modifier)
+	case *CallNode:
+		n.Function = modifyOne[Expression](n, n.Function, modifier)
+		n.Arguments = modifyAll(n.Arguments, modifier)
+	case *PrefixNode:
+		n.Right = modifyOne[Expression](n, n.Right, modifier)
+	case *InfixNode:
+		n.Left = modifyOne[Expression](n, n.Left, modifier)
+		n.Right = modifyOne[Expression](n, n.Right, modifier)
+	case *ConditionalNode:
+		n.Condition = modifyOne[Expression](n, n.Condition, modifier)
+		n.Consequence = modifyOne[Expression](n, n.Consequence, modifier)
+		n.Alternative = modifyOne[Expression](n, n.Alternative, modifier)
+	case *AssignNode:
+		n.Name = modifyOne[*IdentifierNode](n, n.Name, modifier)
+		n.Value = modifyOne[Expression](n, n.Value, modifier)
+	case *RangeNode:
+		n.Start = modifyOne[Expression](n, n.Start, modifier)
+		n.End = modifyOne[Expression](n, n.End, modifier)
+	case *TypeNode:
+		n.Parameters = modifyAll(n.Parameters, modifier)
+		n.Return = modifyOne[*TypeNode](n, n.Return, modifier)
+	}
+
+	return modifier(node)
+}
+
+func modifyOne[T Node](parent Node, child T, modifier ModifierFunc) T {
+	var zero T
+
+	if isNil(child) {
+		return child
+	}
+
+	replacement := Modify(child, modifier)
+	if isNil(replacement) {
+		return zero
+	}
+
+	result, ok := replacement.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast: cannot replace %T with %T in %T", child, replacement, parent))
+	}
+
+	return result
+}
+
+func modifyAll[T Node](children []T, modifier ModifierFunc) []T {
+	if children == nil {
+		return nil
+	}
+
+	result := children[:0]
+
+	for _, child := range children {
+		if isNil(child) {
+			continue
+		}
+
+		replacement := Modify(child, modifier)
+		if isNil(replacement) {
+			continue
+		}
+
+		next, ok := replacement.(T)
+		if !ok {
+			panic(fmt.Sprintf("ast: cannot replace %T with %T in a list of %T", child, replacement, child))
+		}
+
+		result = append(result, next)
+	}
+
+	return result
+}
+
+// isNil, returns true for nil interfaces and interfaces holding a nil pointer.
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
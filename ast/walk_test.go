@@ -0,0 +1,142 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func program() *RootNode {
+	return Root(
+		Let(
+			Identifier("add"),
+			Function(Block(Return(Infix(Identifier("x"), "+", Identifier("y")))), Identifier("x"), Identifier("y")),
+		),
+		ExpressionStatement(
+			If(
+				Prefix("!", True()),
+				Block(ExpressionStatement(Call(Identifier("add"), Integer(1), Integer(2)))),
+				nil,
+			),
+		),
+	)
+}
+
+func Test_Inspect(t *testing.T) {
+	var visited []string
+
+	Inspect(program(), func(n Node) bool {
+		if n != nil {
+			visited = append(visited, fmt.Sprintf("%T", n))
+		}
+		return true
+	})
+
+	expected := []string{
+		"*ast.RootNode",
+		"*ast.LetNode", "*ast.IdentifierNode",
+		"*ast.FunctionNode", "*ast.IdentifierNode", "*ast.IdentifierNode",
+		"*ast.BlockNode", "*ast.ReturnNode", "*ast.InfixNode", "*ast.IdentifierNode", "*ast.IdentifierNode",
+		"*ast.ExpressionStatementNode", "*ast.IfNode", "*ast.PrefixNode", "*ast.BooleanNode",
+		"*ast.BlockNode", "*ast.ExpressionStatementNode",
+		"*ast.CallNode", "*ast.IdentifierNode", "*ast.IntegerNode", "*ast.IntegerNode",
+	}
+	assert.Equal(t, expected, visited)
+}
+
+func Test_Inspect_Prune(t *testing.T) {
+	var visited int
+
+	Inspect(program(), func(n Node) bool {
+		if n != nil {
+			visited++
+		}
+		_, fn := n.(*FunctionNode)
+		return !fn
+	})
+
+	assert.Equal(t, 14, visited)
+}
+
+type depthVisitor struct {
+	depth int
+	max   *int
+}
+
+func (v depthVisitor) Visit(n Node) Visitor {
+	if n == nil {
+		return nil
+	}
+	*v.max = max(*v.max, v.depth)
+	return depthVisitor{depth: v.depth + 1, max: v.max}
+}
+
+func Test_Walk(t *testing.T) {
+	deepest := 0
+	Walk(depthVisitor{max: &deepest}, program())
+	assert.Equal(t, 6, deepest)
+}
+
+func Test_Modify(t *testing.T) {
+	root := program()
+
+	// Rename x to a everywhere, including the parameter list.
+	Modify(root, func(n Node) Node {
+		if id, ok := n.(*IdentifierNode); ok && id.Value == "x" {
+			return Identifier("a")
+		}
+		return n
+	})
+
+	// Drop the second argument of the call.
+	Modify(root, func(n Node) Node {
+		if i, ok := n.(*IntegerNode); ok && i.Value == 2 {
+			return nil
+		}
+		return n
+	})
+
+	var buf bytes.Buffer
+	NewWriter(&buf).Write(root)
+
+	expected := `let add = fn(a, y) {
+	return (a + y);
+};
+if (!true) {
+	add(1)
+}`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_Modify_Statements(t *testing.T) {
+	root := Root(
+		ExpressionStatement(Integer(1)),
+		Return(Integer(2)),
+		ExpressionStatement(Integer(3)),
+	)
+
+	result := Modify(root, func(n Node) Node {
+		if _, ok := n.(*ReturnNode); ok {
+			return nil
+		}
+		if i, ok := n.(*IntegerNode); ok {
+			return Integer(i.Value * 10)
+		}
+		return n
+	})
+
+	assert.Equal(t, Root(ExpressionStatement(Integer(10)), ExpressionStatement(Integer(30))), result)
+}
+
+func Test_Modify_InvalidReplacement(t *testing.T) {
+	assert.PanicsWithValue(t, "ast: cannot replace *ast.IntegerNode with *ast.ReturnNode in *ast.InfixNode", func() {
+		Modify(Infix(Integer(1), "+", Integer(2)), func(n Node) Node {
+			if i, ok := n.(*IntegerNode); ok {
+				return Return(i)
+			}
+			return n
+		})
+	})
+}
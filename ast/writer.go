@@ -21,6 +21,8 @@ func (w *Writer) Write(node Node) {
 		w.Integer(n)
 	case *BooleanNode:
 		w.Boolean(n)
+	case *NullNode:
+		fmt.Fprint(w.writer, "null")
 	case *IdentifierNode:
 		w.Identifier(n)
 	case *BlockNode:
@@ -37,14 +39,30 @@ func (w *Writer) Write(node Node) {
 		w.Infix(n)
 	case *FunctionNode:
 		w.Function(n)
+	case *MacroNode:
+		w.Macro(n)
 	case *IfNode:
 		w.If(n)
+	case *WhileNode:
+		w.While(n)
+	case *ForNode:
+		w.For(n)
+	case *ConditionalNode:
+		w.Conditional(n)
+	case *AssignNode:
+		w.Assign(n)
+	case *RangeNode:
+		w.Range(n)
+	case *BreakNode:
+		fmt.Fprint(w.writer, "break;")
+	case *ContinueNode:
+		fmt.Fprint(w.writer, "continue;")
+	case *TypeNode:
+		w.Type(n)
 	case *ExpressionStatementNode:
 		w.ExpressionStatement(n)
 	case *RootNode:
-		for _, stmt := range n.Statements {
-			w.Write(stmt)
-		}
+		w.Statements(n.Statements)
 	default:
 		fmt.Fprintf(w.writer, "<%T>", n)
 	}
@@ -73,12 +91,28 @@ func (w *Writer) Identifier(node *IdentifierNode) {
 func (w *Writer) Block(node *BlockNode) {
 	fmt.Fprint(w.writer, "{\n")
 	w.indent++
-	for _, stmt := range node.Statements {
+	w.Statements(node.Statements)
+	w.indent--
+	fmt.Fprint(w.writer, "\n}")
+}
+
+// Statements, writes each statement on a line of its own. An expression followed by
+// another statement ends with a semicolon, so the two are not read back as one.
+func (w *Writer) Statements(stmts []Statement) {
+	for i, stmt := range stmts {
+		if i > 0 {
+			// A let already ends its own line.
+			if _, ok := stmts[i-1].(*LetNode); !ok {
+				fmt.Fprint(w.writer, "\n")
+			}
+		}
 		fmt.Fprint(w.writer, w.Indentation())
 		w.Write(stmt)
+
+		if _, ok := stmt.(*ExpressionStatementNode); ok && i < len(stmts)-1 {
+			fmt.Fprint(w.writer, ";")
+		}
 	}
-	w.indent--
-	fmt.Fprint(w.writer, "\n}")
 }
 
 func (w *Writer) Return(node *ReturnNode) {
@@ -90,6 +124,10 @@ func (w *Writer) Return(node *ReturnNode) {
 func (w *Writer) Let(node *LetNode) {
 	fmt.Fprint(w.writer, "let ")
 	w.Identifier(node.Identifier)
+	if node.Annotation != nil {
+		fmt.Fprint(w.writer, ": ")
+		w.Type(node.Annotation)
+	}
 	fmt.Fprint(w.writer, " = ")
 	w.Write(node.Value)
 	fmt.Fprint(w.writer, ";\n")
@@ -121,28 +159,142 @@ func (w *Writer) Infix(node *InfixNode) {
 }
 
 func (w *Writer) Function(node *FunctionNode) {
+	fmt.Fprint(w.writer, "fn")
+	w.Parameters(node.Parameters, node.ParameterTypes)
+	if node.ReturnType != nil {
+		fmt.Fprint(w.writer, " -> ")
+		w.Type(node.ReturnType)
+	}
+	fmt.Fprint(w.writer, " ")
+	w.Block(node.Body)
+}
+
+func (w *Writer) Macro(node *MacroNode) {
+	fmt.Fprint(w.writer, "macro")
+	w.Parameters(node.Parameters, nil)
+	fmt.Fprint(w.writer, " ")
+	w.Block(node.Body)
+}
+
+// Parameters, writes a parenthesised parameter list with the annotations in types, if any.
+func (w *Writer) Parameters(params []*IdentifierNode, types []*TypeNode) {
+	fmt.Fprint(w.writer, "(")
+	for i, param := range params {
+		w.Identifier(param)
+		if i < len(types) && types[i] != nil {
+			fmt.Fprint(w.writer, ": ")
+			w.Type(types[i])
+		}
+		if i < len(params)-1 {
+			fmt.Fprint(w.writer, ", ")
+		}
+	}
+	fmt.Fprint(w.writer, ")")
+}
+
+func (w *Writer) Type(node *TypeNode) {
+	if !node.IsFunction() {
+		fmt.Fprint(w.writer, node.Name)
+		return
+	}
+
 	fmt.Fprint(w.writer, "fn(")
 	for i, param := range node.Parameters {
-		w.Identifier(param)
+		w.Type(param)
 		if i < len(node.Parameters)-1 {
 			fmt.Fprint(w.writer, ", ")
 		}
 	}
-	fmt.Fprint(w.writer, ") ")
-	w.Block(node.Body)
+	fmt.Fprint(w.writer, ") -> ")
+	w.Type(node.Return)
 }
 
 func (w *Writer) If(node *IfNode) {
 	fmt.Fprint(w.writer, "if ")
-	w.Write(node.Condition)
+	w.Condition(node.Condition)
 	fmt.Fprint(w.writer, " ")
 	w.Block(node.Consequence)
 	if node.Alternative != nil {
 		fmt.Fprint(w.writer, " else ")
-		w.Block(node.Alternative)
+		// An else holding only an if is written as else if.
+		if next, ok := elseIf(node.Alternative); ok {
+			w.If(next)
+		} else {
+			w.Block(node.Alternative)
+		}
 	}
 }
 
+// elseIf, returns the if that is the only statement of block.
+func elseIf(block *BlockNode) (*IfNode, bool) {
+	if len(block.Statements) != 1 {
+		return nil, false
+	}
+
+	stmt, ok := block.Statements[0].(*ExpressionStatementNode)
+	if !ok {
+		return nil, false
+	}
+
+	n, ok := stmt.Expression.(*IfNode)
+	return n, ok && n != nil
+}
+
+func (w *Writer) Conditional(node *ConditionalNode) {
+	fmt.Fprint(w.writer, "(")
+	w.Write(node.Condition)
+	fmt.Fprint(w.writer, " ? ")
+	w.Write(node.Consequence)
+	fmt.Fprint(w.writer, " : ")
+	w.Write(node.Alternative)
+	fmt.Fprint(w.writer, ")")
+}
+
+// Condition, writes the condition of an if or a while in the parentheses the parser
+// requires, which the operators written in parentheses of their own already have.
+func (w *Writer) Condition(node Expression) {
+	switch node.(type) {
+	case *InfixNode, *RangeNode, *AssignNode, *ConditionalNode:
+		w.Write(node)
+	default:
+		fmt.Fprint(w.writer, "(")
+		w.Write(node)
+		fmt.Fprint(w.writer, ")")
+	}
+}
+
+func (w *Writer) While(node *WhileNode) {
+	fmt.Fprint(w.writer, "while ")
+	w.Condition(node.Condition)
+	fmt.Fprint(w.writer, " ")
+	w.Block(node.Body)
+}
+
+func (w *Writer) For(node *ForNode) {
+	fmt.Fprint(w.writer, "for (")
+	w.Identifier(node.Value)
+	fmt.Fprint(w.writer, " in ")
+	w.Write(node.Iterable)
+	fmt.Fprint(w.writer, ") ")
+	w.Block(node.Body)
+}
+
+func (w *Writer) Assign(node *AssignNode) {
+	fmt.Fprint(w.writer, "(")
+	w.Identifier(node.Name)
+	fmt.Fprintf(w.writer, " %s ", node.Operator)
+	w.Write(node.Value)
+	fmt.Fprint(w.writer, ")")
+}
+
+func (w *Writer) Range(node *RangeNode) {
+	fmt.Fprint(w.writer, "(")
+	w.Write(node.Start)
+	fmt.Fprint(w.writer, "..")
+	w.Write(node.End)
+	fmt.Fprint(w.writer, ")")
+}
+
 func (w *Writer) ExpressionStatement(node *ExpressionStatementNode) {
 	w.Write(node.Expression)
 }
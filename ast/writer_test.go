@@ -24,7 +24,27 @@ func Test_Writer_Write(t *testing.T) {
 		{name: "prefix", given: Prefix("-", Integer(5)), expected: "-5"},
 		{name: "infix", given: Infix(Integer(5), "+", Integer(5)), expected: "(5 + 5)"},
 		{name: "function", given: Function(Block(Return(Identifier("x"))), Identifier("x")), expected: "fn(x) {\n\treturn x;\n}"},
+		{name: "macro", given: Macro(Block(Return(Identifier("x"))), Identifier("x"), Identifier("y")), expected: "macro(x, y) {\n\treturn x;\n}"},
+		{
+			name: "annotated function",
+			given: &FunctionNode{
+				Parameters:     []*IdentifierNode{Identifier("f"), Identifier("x")},
+				ParameterTypes: []*TypeNode{FunctionType(Type("bool"), Type("int")), nil},
+				ReturnType:     Type("bool"),
+				Body:           Block(Return(Call(Identifier("f"), Identifier("x")))),
+			},
+			expected: "fn(f: fn(int) -> bool, x) -> bool {\n\treturn f(x);\n}",
+		},
+		{name: "annotated let", given: &LetNode{Identifier: Identifier("x"), Annotation: Type("int"), Value: Integer(5)}, expected: "let x: int = 5;\n"},
 		{name: "if", given: If(Infix(Identifier("x"), "<", Integer(10)), Block(Return(True())), Block(Return(False()))), expected: "if (x < 10) {\n\treturn true;\n} else {\n\treturn false;\n}"},
+		{name: "while", given: While(Infix(Identifier("x"), "<", Integer(10)), Block(Break())), expected: "while (x < 10) {\n\tbreak;\n}"},
+		{name: "continue", given: Continue(), expected: "continue;"},
+		{name: "for", given: For(Identifier("i"), Range(Integer(0), Identifier("n")), Block(Continue())), expected: "for (i in (0..n)) {\n\tcontinue;\n}"},
+		{name: "assign", given: Assign(Identifier("x"), "=", Assign(Identifier("y"), "*=", Integer(2))), expected: "(x = (y *= 2))"},
+		{name: "else if", given: If(True(), Block(), Block(ExpressionStatement(If(False(), Block(), nil)))), expected: "if (true) {\n\n} else if (false) {\n\n}"},
+		{name: "else with more than an if", given: If(True(), Block(), Block(Return(If(False(), Block(), nil)))), expected: "if (true) {\n\n} else {\n\treturn if (false) {\n\n};\n}"},
+		{name: "conditional", given: Conditional(Identifier("a"), Integer(1), Conditional(Identifier("b"), Integer(2), Integer(3))), expected: "(a ? 1 : (b ? 2 : 3))"},
+		{name: "null", given: Infix(Identifier("x"), "??", Null()), expected: "(x ?? null)"},
 		{name: "expression statement", given: ExpressionStatement(Infix(Integer(5), "+", Integer(5))), expected: "(5 + 5)"},
 	}
 
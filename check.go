@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/evaluator"
+	"github.com/maybe-joe/monkey/object"
+	"github.com/maybe-joe/monkey/resolver"
+	"github.com/maybe-joe/monkey/types"
+)
+
+func checkSource(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	format := flags.String("diagnostics", "text", "how to report problems, text or json")
+	show := flags.Bool("types", false, "print the type of each top level let")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	src, err := readSource(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	root, err := parse(src, *format)
+	if err != nil {
+		return err
+	}
+
+	macros := object.NewEnvironment()
+	evaluator.DefineMacros(root, macros)
+
+	expanded, failure := evaluator.ExpandMacros(root, macros)
+	if failure != nil {
+		return report(src, []diagnostics.Diagnostic{failure.Diagnostic(src)}, *format)
+	}
+
+	r := resolver.New()
+	r.Resolve(expanded)
+
+	c := types.New()
+	c.Check(expanded)
+
+	if err := report(src, append(r.Errors(), c.Diagnostics()...), *format); err != nil {
+		return err
+	}
+
+	if *show {
+		for _, stmt := range expanded.Statements {
+			if let, ok := stmt.(*ast.LetNode); ok {
+				if t, ok := c.TypeOf(let.Identifier); ok {
+					fmt.Printf("%s: %s\n", let.Identifier.Value, types.String(t))
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package cst
+
+import (
+	"strings"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+)
+
+type TriviaKind string
+
+const (
+	Whitespace TriviaKind = "whitespace"
+	Comment    TriviaKind = "comment"
+)
+
+// Trivia, code between tokens that the parser skips.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+	Span token.Span
+}
+
+// Token, a token with the trivia before it.
+type Token struct {
+	Token token.Token
+	// Text, the code of the token. Changing it changes what the tree prints.
+	Text string
+	Span token.Span
+	// Leading, the whitespace and comments between the previous token and this one.
+	Leading []Trivia
+}
+
+func (*Token) element() {}
+
+func (t *Token) Location() token.Span {
+	return t.Span
+}
+
+// Element, a token or a node in a Node.
+type Element interface {
+	element()
+	Location() token.Span
+}
+
+// Node, the tokens of an ast node, with the nodes below it in place of their tokens.
+type Node struct {
+	AST      ast.Node
+	Elements []Element
+}
+
+func (*Node) element() {}
+
+func (n *Node) Location() token.Span {
+	return n.AST.Location()
+}
+
+// Tokens, returns every token of n and the nodes below it in the order written.
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, e := range n.Elements {
+		switch e := e.(type) {
+		case *Token:
+			tokens = append(tokens, e)
+		case *Node:
+			tokens = append(tokens, e.Tokens()...)
+		}
+	}
+
+	return tokens
+}
+
+// String, returns the code of n with the trivia before its first token.
+func (n *Node) String() string {
+	var b strings.Builder
+	for _, t := range n.Tokens() {
+		for _, trivia := range t.Leading {
+			b.WriteString(trivia.Text)
+		}
+		b.WriteString(t.Text)
+	}
+
+	return b.String()
+}
+
+// Text, returns the code of n from its first token to its last.
+func (n *Node) Text() string {
+	tokens := n.Tokens()
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 {
+			for _, trivia := range t.Leading {
+				b.WriteString(trivia.Text)
+			}
+		}
+		b.WriteString(t.Text)
+	}
+
+	return b.String()
+}
+
+// Tree, a lossless view of code: every token and the trivia between them, grouped
+// by the ast nodes they belong to. Printing the tree gives back the code byte for byte,
+// or the code with only the tokens whose text has been changed rewritten.
+type Tree struct {
+	Code string
+	Root *Node
+	// Tokens, every token in the code, ending with the end of file whose trivia is
+	// the whitespace and comments after the last token.
+	Tokens []*Token
+
+	nodes map[ast.Node]*Node
+}
+
+// Parse, parses code returning its tree and any problems found.
+// Code that does not parse still has a tree, with the tokens of broken statements in the root.
+func Parse(code string) (*Tree, []diagnostics.Diagnostic) {
+	p := parser.New(token.NewTokenizer(code))
+	root := p.Parse()
+
+	return Build(code, root), p.Diagnostics()
+}
+
+// Build, returns the tree for root, which must have been parsed from code.
+func Build(code string, root *ast.RootNode) *Tree {
+	t := &Tree{Code: code, nodes: map[ast.Node]*Node{}}
+	t.tokenize()
+
+	b := &builder{tree: t}
+	t.Root = b.build(root)
+
+	return t
+}
+
+// tokenize, splits the code into tokens and the trivia between them.
+func (t *Tree) tokenize() {
+	tz := token.NewTokenizer(t.Code)
+
+	var spans []token.Span
+	var tokens []token.Token
+	for {
+		tok := tz.Next()
+		tokens = append(tokens, tok)
+		spans = append(spans, tz.Span())
+		if tok.Is(token.EOF) {
+			break
+		}
+	}
+
+	comments := tz.Comments()
+	end := 0
+	for i, tok := range tokens {
+		span := spans[i]
+
+		var leading []Trivia
+		for end < span.Start {
+			if len(comments) > 0 && comments[0].Span.Start == end {
+				c := comments[0]
+				comments = comments[1:]
+				leading = append(leading, Trivia{Kind: Comment, Text: c.Text, Span: c.Span})
+				end = c.Span.End
+				continue
+			}
+
+			// Whitespace runs up to the next comment or the token.
+			next := span.Start
+			if len(comments) > 0 && comments[0].Span.Start < next {
+				next = comments[0].Span.Start
+			}
+			leading = append(leading, Trivia{Kind: Whitespace, Text: t.Code[end:next], Span: token.Span{Start: end, End: next}})
+			end = next
+		}
+
+		t.Tokens = append(t.Tokens, &Token{Token: tok, Text: t.Code[span.Start:span.End], Span: span, Leading: leading})
+		end = span.End
+	}
+}
+
+// builder, hands out the tokens in order to the nodes they fall within.
+type builder struct {
+	tree *Tree
+	next int
+}
+
+func (b *builder) build(n ast.Node) *Node {
+	node := &Node{AST: n}
+	b.tree.nodes[n] = node
+
+	span := n.Location()
+	for _, child := range ast.Children(n) {
+		start := child.Location().Start
+		for b.next < len(b.tree.Tokens) && b.tree.Tokens[b.next].Span.Start < start {
+			node.Elements = append(node.Elements, b.tree.Tokens[b.next])
+			b.next++
+		}
+
+		node.Elements = append(node.Elements, b.build(child))
+	}
+
+	for b.next < len(b.tree.Tokens) && b.tree.Tokens[b.next].Span.End <= span.End {
+		node.Elements = append(node.Elements, b.tree.Tokens[b.next])
+		b.next++
+	}
+
+	return node
+}
+
+// Node, returns the node for an ast node of the tree.
+func (t *Tree) Node(n ast.Node) (*Node, bool) {
+	node, ok := t.nodes[n]
+	return node, ok
+}
+
+// TokenAt, returns the token under offset.
+func (t *Tree) TokenAt(offset int) (*Token, bool) {
+	for _, tok := range t.Tokens {
+		if tok.Span.Start <= offset && offset < tok.Span.End {
+			return tok, true
+		}
+	}
+
+	return nil, false
+}
+
+// String, returns the code of the tree, with the tokens whose text has changed rewritten.
+func (t *Tree) String() string {
+	return t.Root.String()
+}
+
+// Edits, returns the changes to the code made by changing the text of tokens,
+// each a single token, in the order written.
+func (t *Tree) Edits() []parser.Edit {
+	var edits []parser.Edit
+	for _, tok := range t.Tokens {
+		if tok.Text != t.Code[tok.Span.Start:tok.Span.End] {
+			edits = append(edits, parser.Edit{Span: tok.Span, Text: tok.Text})
+		}
+	}
+
+	return edits
+}
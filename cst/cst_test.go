@@ -0,0 +1,106 @@
+package cst
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Tree_RoundTrip(t *testing.T) {
+	testcases := []struct {
+		name  string
+		given string
+	}{
+		{name: "empty", given: ""},
+		{name: "whitespace", given: " \n\t\n"},
+		{name: "comments only", given: "// one\n// two"},
+		{name: "program", given: "let add = fn(a, b) { a + b };\n\nadd(1, 2)\n"},
+		{name: "odd spacing", given: "let   x=( 1+2 ) *3 ;;\n  if(x>1){x}else{ -x }"},
+		{name: "comments", given: "// header\nlet x = 1; // trailing\n  // indented\nx // last"},
+		{name: "windows line endings", given: "let x = 1;\r\nx\r\n"},
+		{name: "annotations", given: "let f: fn(int) -> int = fn(n: int) -> int { n };"},
+		{name: "macro", given: "let m = macro(a) { quote(unquote(a)) };"},
+		{name: "else if and conditional", given: "if (a) { 1 } else  if (b) { 2 } else { c ? 3 : 4 }"},
+		{name: "syntax errors", given: "let = 1; let y = (2;\n@ fn(a { a"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, _ := Parse(tc.given)
+			assert.Equal(t, tc.given, tree.String())
+			assert.Empty(t, tree.Edits())
+
+			last := tree.Tokens[len(tree.Tokens)-1]
+			assert.Equal(t, token.EOF, last.Token.Type)
+		})
+	}
+}
+
+func Test_Tree_Trivia(t *testing.T) {
+	tree, diags := Parse("let x = 1; // one\n\n// two\nx")
+	require.Empty(t, diags)
+
+	x := tree.Tokens[5]
+	assert.Equal(t, "x", x.Text)
+	assert.Equal(t, []Trivia{
+		{Kind: Whitespace, Text: " ", Span: token.Span{Start: 10, End: 11}},
+		{Kind: Comment, Text: "// one", Span: token.Span{Start: 11, End: 17}},
+		{Kind: Whitespace, Text: "\n\n", Span: token.Span{Start: 17, End: 19}},
+		{Kind: Comment, Text: "// two", Span: token.Span{Start: 19, End: 25}},
+		{Kind: Whitespace, Text: "\n", Span: token.Span{Start: 25, End: 26}},
+	}, x.Leading)
+}
+
+func Test_Tree_Node(t *testing.T) {
+	code := "let add = fn(a, b) {\n\t(a + b) // sum\n};\nadd(1, 2);"
+	p := parser.New(token.NewTokenizer(code))
+	root := p.Parse()
+	require.Empty(t, p.Errors())
+
+	tree := Build(code, root)
+
+	let := root.Statements[0].(*ast.LetNode)
+	node, ok := tree.Node(let)
+	require.True(t, ok)
+	assert.Equal(t, "let add = fn(a, b) {\n\t(a + b) // sum\n};", node.Text())
+
+	body, ok := tree.Node(let.Value.(*ast.FunctionNode).Body)
+	require.True(t, ok)
+	assert.Equal(t, "{\n\t(a + b) // sum\n}", body.Text())
+	assert.Equal(t, " {\n\t(a + b) // sum\n}", body.String())
+
+	sum, ok := tree.Node(let.Value.(*ast.FunctionNode).Body.Statements[0].(*ast.ExpressionStatementNode).Expression)
+	require.True(t, ok)
+	assert.Equal(t, "a + b", sum.Text())
+
+	call, ok := tree.Node(root.Statements[1].(*ast.ExpressionStatementNode).Expression)
+	require.True(t, ok)
+	assert.Equal(t, "add(1, 2)", call.Text())
+	assert.Len(t, call.Elements, 6)
+
+	tok, ok := tree.TokenAt(5)
+	require.True(t, ok)
+	assert.Equal(t, "add", tok.Text)
+}
+
+func Test_Tree_Edits(t *testing.T) {
+	tree, diags := Parse("let a = 1; // the a\nlet f = fn(x) {   a  +  x };\nf(a)")
+	require.Empty(t, diags)
+
+	for _, tok := range tree.Tokens {
+		if tok.Token.Is(token.IDENT) && tok.Text == "a" {
+			tok.Text = "count"
+		}
+	}
+
+	assert.Equal(t, "let count = 1; // the a\nlet f = fn(x) {   count  +  x };\nf(count)", tree.String())
+	assert.Equal(t, []parser.Edit{
+		{Span: token.Span{Start: 4, End: 5}, Text: "count"},
+		{Span: token.Span{Start: 38, End: 39}, Text: "count"},
+		{Span: token.Span{Start: 51, End: 52}, Text: "count"},
+	}, tree.Edits())
+}
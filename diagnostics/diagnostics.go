@@ -0,0 +1,180 @@
+package diagnostics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/maybe-joe/monkey/token"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Label, points at a span of the code with an optional message.
+// The primary label marks where the problem is, secondary labels give context.
+type Label struct {
+	Span    token.Span
+	Message string
+	Primary bool
+}
+
+// Diagnostic, a problem found in some code.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Labels   []Label
+	Hint     string
+	Notes    []string
+}
+
+// Errorf creates an error diagnostic with a primary label at span.
+func Errorf(span token.Span, format string, args ...any) Diagnostic {
+	return Diagnostic{
+		Severity: Error,
+		Message:  fmt.Sprintf(format, args...),
+		Labels:   []Label{{Span: span, Primary: true}},
+	}
+}
+
+// Warningf creates a warning diagnostic with a primary label at span.
+func Warningf(span token.Span, format string, args ...any) Diagnostic {
+	d := Errorf(span, format, args...)
+	d.Severity = Warning
+	return d
+}
+
+// WithLabel returns a copy of d with a secondary label added.
+func (d Diagnostic) WithLabel(span token.Span, message string) Diagnostic {
+	d.Labels = append(d.Labels[:len(d.Labels):len(d.Labels)], Label{Span: span, Message: message})
+	return d
+}
+
+// WithMessage returns a copy of d with the message of the primary label set.
+func (d Diagnostic) WithMessage(message string) Diagnostic {
+	labels := make([]Label, len(d.Labels))
+	copy(labels, d.Labels)
+	for i := range labels {
+		if labels[i].Primary {
+			labels[i].Message = message
+		}
+	}
+	d.Labels = labels
+	return d
+}
+
+// WithHint returns a copy of d with the hint set.
+func (d Diagnostic) WithHint(hint string) Diagnostic {
+	d.Hint = hint
+	return d
+}
+
+// WithNote returns a copy of d with a note added.
+func (d Diagnostic) WithNote(note string) Diagnostic {
+	d.Notes = append(d.Notes[:len(d.Notes):len(d.Notes)], note)
+	return d
+}
+
+// Shift returns a copy of d with its labels moved by delta bytes, as when code before them has been edited.
+func (d Diagnostic) Shift(delta int) Diagnostic {
+	labels := make([]Label, len(d.Labels))
+	for i, l := range d.Labels {
+		l.Span = l.Span.Shift(delta)
+		labels[i] = l
+	}
+	d.Labels = labels
+	return d
+}
+
+// Primary, returns the span of the primary label, or the first label if none are primary.
+func (d Diagnostic) Primary() (token.Span, bool) {
+	for _, l := range d.Labels {
+		if l.Primary {
+			return l.Span, true
+		}
+	}
+
+	if len(d.Labels) > 0 {
+		return d.Labels[0].Span, true
+	}
+
+	return token.Span{}, false
+}
+
+func (d Diagnostic) Error() string {
+	return d.Message
+}
+
+// Position, a 1 based line and column in a Source.
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Source, the named code diagnostics refer to.
+type Source struct {
+	Name string
+	Code string
+	// lines, the offset each line starts at.
+	lines []int
+}
+
+// NewSource creates a Source for code read from the given name.
+func NewSource(name, code string) *Source {
+	lines := []int{0}
+	for i := 0; i < len(code); i++ {
+		if code[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	return &Source{Name: name, Code: code, lines: lines}
+}
+
+// Append, adds code to the end of the source, indexing only the lines it adds.
+func (s *Source) Append(code string) {
+	for i := 0; i < len(code); i++ {
+		if code[i] == '\n' {
+			s.lines = append(s.lines, len(s.Code)+i+1)
+		}
+	}
+
+	s.Code += code
+}
+
+// Position, returns the line and column of the offset into the code.
+func (s *Source) Position(offset int) Position {
+	offset = max(0, min(offset, len(s.Code)))
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset }) - 1
+
+	return Position{
+		Offset: offset,
+		Line:   line + 1,
+		Column: offset - s.lines[line] + 1,
+	}
+}
+
+// Offset, returns the offset of the 1 based line and column, clamped to the code.
+func (s *Source) Offset(line, column int) int {
+	line = max(1, min(line, len(s.lines)))
+	return max(0, min(s.lines[line-1]+column-1, len(s.Code)))
+}
+
+// Line, returns the text of the 1 based line without its line ending.
+func (s *Source) Line(n int) string {
+	if n < 1 || n > len(s.lines) {
+		return ""
+	}
+
+	end := len(s.Code)
+	if n < len(s.lines) {
+		end = s.lines[n] - 1
+	}
+
+	return strings.TrimSuffix(s.Code[s.lines[n-1]:end], "\r")
+}
@@ -0,0 +1,129 @@
+package diagnostics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Source_Position(t *testing.T) {
+	src := NewSource("main.mk", "let x = 1;\r\nlet y = 2;\n")
+
+	assert.Equal(t, Position{Offset: 0, Line: 1, Column: 1}, src.Position(0))
+	assert.Equal(t, Position{Offset: 4, Line: 1, Column: 5}, src.Position(4))
+	assert.Equal(t, Position{Offset: 16, Line: 2, Column: 5}, src.Position(16))
+	assert.Equal(t, Position{Offset: 23, Line: 3, Column: 1}, src.Position(100))
+	assert.Equal(t, 16, src.Offset(2, 5))
+	assert.Equal(t, "let x = 1;", src.Line(1))
+	assert.Equal(t, "let y = 2;", src.Line(2))
+	assert.Equal(t, "", src.Line(3))
+}
+
+func Test_Source_Append(t *testing.T) {
+	src := NewSource("repl", "let x = 1;\n")
+	src.Append("let y = 2;\n")
+	src.Append("x + y")
+
+	assert.Equal(t, NewSource("repl", "let x = 1;\nlet y = 2;\nx + y"), src)
+	assert.Equal(t, Position{Offset: 22, Line: 3, Column: 1}, src.Position(22))
+	assert.Equal(t, "let y = 2;", src.Line(2))
+}
+
+func Test_Renderer(t *testing.T) {
+	src := NewSource("main.mk", "let x = 1;\nlet y = (1 + 2;\n")
+
+	d := Errorf(token.Span{Start: 24, End: 25}, "expected ')', found ';'").
+		WithMessage("expected ')'").
+		WithLabel(token.Span{Start: 19, End: 20}, "unclosed '('").
+		WithHint("expected ')' to close '(' opened here")
+
+	var buf bytes.Buffer
+	NewRenderer(&buf, src).Render(d)
+
+	expected := `error: expected ')', found ';'
+ --> main.mk:2:14
+  |
+2 | let y = (1 + 2;
+  |              ^ expected ')'
+  |         - unclosed '('
+  = hint: expected ')' to close '(' opened here
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_Renderer_SeveralLines(t *testing.T) {
+	code := "let f = fn(x) {\n  x + true;\n};\n"
+	src := NewSource("f.mk", code)
+
+	d := Errorf(token.Span{Start: 18, End: 26}, "type mismatch: INTEGER + BOOLEAN").
+		WithLabel(token.Span{Start: 8, End: 29}, "in this function").
+		WithNote("called from main.mk:4:1")
+
+	var buf bytes.Buffer
+	NewRenderer(&buf, src).Render(d)
+
+	expected := `error: type mismatch: INTEGER + BOOLEAN
+ --> f.mk:2:3
+  |
+1 | let f = fn(x) {
+  |         ------- in this function
+2 |   x + true;
+  |   ^^^^^^^^
+  = note: called from main.mk:4:1
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_WriteJSON(t *testing.T) {
+	src := NewSource("main.mk", "(1 + 2;")
+
+	d := Errorf(token.Span{Start: 6, End: 7}, "expected ')', found ';'").
+		WithLabel(token.Span{Start: 0, End: 1}, "unclosed '('")
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, src, []Diagnostic{d}))
+
+	expected := `[
+  {
+    "file": "main.mk",
+    "severity": "error",
+    "message": "expected ')', found ';'",
+    "line": 1,
+    "column": 7,
+    "labels": [
+      {
+        "primary": true,
+        "start": {
+          "offset": 6,
+          "line": 1,
+          "column": 7
+        },
+        "end": {
+          "offset": 7,
+          "line": 1,
+          "column": 8
+        }
+      },
+      {
+        "message": "unclosed '('",
+        "primary": false,
+        "start": {
+          "offset": 0,
+          "line": 1,
+          "column": 1
+        },
+        "end": {
+          "offset": 1,
+          "line": 1,
+          "column": 2
+        }
+      }
+    ]
+  }
+]
+`
+	assert.Equal(t, expected, buf.String())
+}
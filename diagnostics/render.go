@@ -0,0 +1,184 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/maybe-joe/monkey/highlight"
+)
+
+// Renderer, writes diagnostics as text with excerpts of the code they refer to.
+//
+//	error: expected ')', found ';'
+//	 --> main.mk:1:7
+//	  |
+//	1 | (1 + 2;
+//	  |       ^ expected ')'
+//	  | - unclosed '('
+//	  = hint: expected ')' to close '(' opened here
+type Renderer struct {
+	writer io.Writer
+	source *Source
+	// Formatter, used to highlight the excerpts and markers.
+	Formatter highlight.Formatter
+}
+
+func NewRenderer(w io.Writer, src *Source) *Renderer {
+	return &Renderer{writer: w, source: src, Formatter: highlight.Text{}}
+}
+
+func (r *Renderer) Render(diags ...Diagnostic) {
+	for _, d := range diags {
+		r.Diagnostic(d)
+	}
+}
+
+func (r *Renderer) Diagnostic(d Diagnostic) {
+	fmt.Fprintf(r.writer, "%s: %s\n", d.Severity, d.Message)
+
+	labels := r.Labels(d)
+	gutter := strings.Repeat(" ", r.GutterWidth(labels))
+
+	if span, ok := d.Primary(); ok {
+		pos := r.source.Position(span.Start)
+		fmt.Fprintf(r.writer, "%s--> %s:%d:%d\n", gutter, r.source.Name, pos.Line, pos.Column)
+	} else if len(r.source.Name) > 0 {
+		fmt.Fprintf(r.writer, "%s--> %s\n", gutter, r.source.Name)
+	}
+
+	if len(labels) > 0 {
+		fmt.Fprintf(r.writer, "%s |\n", gutter)
+	}
+
+	line := 0
+	for _, l := range labels {
+		start := r.source.Position(l.Span.Start)
+
+		if start.Line != line {
+			line = start.Line
+			fmt.Fprintf(r.writer, "%*d | ", len(gutter), line)
+			highlight.Source(r.writer, r.source.Line(line), r.Formatter)
+			fmt.Fprint(r.writer, "\n")
+		}
+
+		fmt.Fprintf(r.writer, "%s | %s", gutter, strings.Repeat(" ", start.Column-1))
+		r.Marker(l, start)
+		if len(l.Message) > 0 {
+			fmt.Fprintf(r.writer, " %s", l.Message)
+		}
+		fmt.Fprint(r.writer, "\n")
+	}
+
+	if len(d.Hint) > 0 {
+		fmt.Fprintf(r.writer, "%s = hint: %s\n", gutter, d.Hint)
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(r.writer, "%s = note: %s\n", gutter, note)
+	}
+}
+
+// Marker, writes the underline for a label, '^' for primary labels and '-' otherwise.
+// Spans covering several lines are underlined to the end of their first line.
+func (r *Renderer) Marker(l Label, start Position) {
+	end := r.source.Position(l.Span.End)
+
+	width := end.Column - start.Column
+	if end.Line != start.Line {
+		width = len(r.source.Line(start.Line)) - start.Column + 1
+	}
+	width = max(width, 1)
+
+	if l.Primary {
+		r.Formatter.Format(r.writer, highlight.Illegal, strings.Repeat("^", width))
+	} else {
+		r.Formatter.Format(r.writer, highlight.Operator, strings.Repeat("-", width))
+	}
+}
+
+// Labels, returns the labels of d ordered by line, primary labels first within a line.
+func (r *Renderer) Labels(d Diagnostic) []Label {
+	labels := make([]Label, len(d.Labels))
+	copy(labels, d.Labels)
+
+	sort.SliceStable(labels, func(i, j int) bool {
+		a, b := r.source.Position(labels[i].Span.Start), r.source.Position(labels[j].Span.Start)
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if labels[i].Primary != labels[j].Primary {
+			return labels[i].Primary
+		}
+		return a.Column < b.Column
+	})
+
+	return labels
+}
+
+// GutterWidth, the number of digits needed for the largest line number of the labels.
+func (r *Renderer) GutterWidth(labels []Label) int {
+	width := 1
+	for _, l := range labels {
+		width = max(width, len(strconv.Itoa(r.source.Position(l.Span.Start).Line)))
+	}
+
+	return width
+}
+
+type jsonLabel struct {
+	Message string   `json:"message,omitempty"`
+	Primary bool     `json:"primary"`
+	Start   Position `json:"start"`
+	End     Position `json:"end"`
+}
+
+type jsonDiagnostic struct {
+	File     string      `json:"file"`
+	Severity Severity    `json:"severity"`
+	Message  string      `json:"message"`
+	Line     int         `json:"line,omitempty"`
+	Column   int         `json:"column,omitempty"`
+	Labels   []jsonLabel `json:"labels"`
+	Hint     string      `json:"hint,omitempty"`
+	Notes    []string    `json:"notes,omitempty"`
+}
+
+// WriteJSON, writes diags to w as a JSON array with spans resolved to lines and columns.
+func WriteJSON(w io.Writer, src *Source, diags []Diagnostic) error {
+	out := make([]jsonDiagnostic, 0, len(diags))
+
+	for _, d := range diags {
+		jd := jsonDiagnostic{
+			File:     src.Name,
+			Severity: d.Severity,
+			Message:  d.Message,
+			Labels:   make([]jsonLabel, 0, len(d.Labels)),
+			Hint:     d.Hint,
+			Notes:    d.Notes,
+		}
+
+		if span, ok := d.Primary(); ok {
+			pos := src.Position(span.Start)
+			jd.Line, jd.Column = pos.Line, pos.Column
+		}
+
+		for _, l := range d.Labels {
+			jd.Labels = append(jd.Labels, jsonLabel{
+				Message: l.Message,
+				Primary: l.Primary,
+				Start:   src.Position(l.Span.Start),
+				End:     src.Position(l.Span.End),
+			})
+		}
+
+		out = append(out, jd)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
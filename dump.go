@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/optimizer"
+)
+
+func dumpAst(args []string) error {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	diagnostics := flags.String("diagnostics", "text", "how to report problems, text or json")
+	format := flags.String("format", "source", "how to write the tree, source, sexpr, dot or json")
+	asJSON := flags.Bool("json", false, "shorthand for --format=json")
+	optimize := flags.String("optimize", "", "optimisations to run before writing the tree, as for run")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		*format = "json"
+	}
+
+	src, err := readSource(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	root, err := parse(src, *diagnostics)
+	if err != nil {
+		return err
+	}
+
+	passes, err := optimizer.ParsePasses(*optimize)
+	if err != nil {
+		return err
+	}
+	root = optimizer.Optimize(root, passes)
+
+	switch *format {
+	case "source":
+		ast.NewWriter(os.Stdout).Write(root)
+	case "sexpr":
+		ast.NewSExprWriter(os.Stdout).Write(root)
+	case "dot":
+		ast.NewDotWriter(os.Stdout).Write(root)
+	case "json":
+		data, err := ast.MarshalJSON(root)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	return nil
+}
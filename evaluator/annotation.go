@@ -0,0 +1,44 @@
+package evaluator
+
+import (
+	"bytes"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/object"
+)
+
+// annotationTypes, the object type required by each type name.
+var annotationTypes = map[string]object.ObjectType{
+	"int":   object.INTEGER_OBJ,
+	"bool":  object.BOOLEAN_OBJ,
+	"null":  object.NULL_OBJ,
+	"quote": object.QUOTE_OBJ,
+	"range": object.RANGE_OBJ,
+}
+
+// Conform, returns an error located at node if val is not of the annotated type.
+// Function types only require a function, its own annotations are checked when it is called.
+// What names the value in the error, such as the parameter it is passed to.
+func (e *Evaluator) Conform(val object.Object, annotation *ast.TypeNode, node ast.Node, what string) *object.Error {
+	want := object.FUNCTION_OBJ
+	if !annotation.IsFunction() {
+		t, ok := annotationTypes[annotation.Name]
+		if !ok {
+			return e.Errorf(annotation, "unknown type: %s", annotation.Name)
+		}
+		want = t
+	}
+
+	got := object.NULL_OBJ
+	if val != nil {
+		got = val.Type()
+	}
+
+	if got != want {
+		var buf bytes.Buffer
+		ast.NewWriter(&buf).Write(annotation)
+		return e.Errorf(node, "type mismatch: %s is %s, got %s", what, buf.String(), got)
+	}
+
+	return nil
+}
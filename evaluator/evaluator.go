@@ -0,0 +1,501 @@
+package evaluator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/object"
+)
+
+var (
+	NULL  = &object.Null{}
+	TRUE  = &object.Boolean{Value: true}
+	FALSE = &object.Boolean{Value: false}
+)
+
+// MaxDepth, the deepest the call stack may grow before evaluation fails.
+const MaxDepth = 10000
+
+// Evaluator, walks a tree evaluating each node, keeping track of the function calls in progress.
+type Evaluator struct {
+	stack []object.Frame
+}
+
+func New() *Evaluator {
+	return &Evaluator{}
+}
+
+// Eval is shorthand for New().Eval(node, env).
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New().Eval(node, env)
+}
+
+// Eval, evaluates node in env.
+// Statements that produce no value, such as let, evaluate to nil.
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) object.Object {
+	switch n := node.(type) {
+	case *ast.RootNode:
+		return e.Root(n, env)
+	case *ast.BlockNode:
+		return e.Block(n, env)
+	case *ast.ExpressionStatementNode:
+		return e.Eval(n.Expression, env)
+	case *ast.LetNode:
+		return e.Let(n, env)
+	case *ast.ReturnNode:
+		return e.Return(n, env)
+	case *ast.IntegerNode:
+		return &object.Integer{Value: n.Value}
+	case *ast.BooleanNode:
+		return nativeBoolean(n.Value)
+	case *ast.NullNode:
+		return NULL
+	case *ast.IdentifierNode:
+		return e.Identifier(n, env)
+	case *ast.PrefixNode:
+		return e.Prefix(n, env)
+	case *ast.InfixNode:
+		return e.Infix(n, env)
+	case *ast.IfNode:
+		return e.If(n, env)
+	case *ast.ConditionalNode:
+		return e.Conditional(n, env)
+	case *ast.WhileNode:
+		return e.While(n, env)
+	case *ast.ForNode:
+		return e.For(n, env)
+	case *ast.AssignNode:
+		return e.Assign(n, env)
+	case *ast.RangeNode:
+		return e.Range(n, env)
+	case *ast.BreakNode:
+		return &object.Break{Node: n}
+	case *ast.ContinueNode:
+		return &object.Continue{Node: n}
+	case *ast.FunctionNode:
+		return &object.Function{
+			Parameters:     n.Parameters,
+			ParameterTypes: n.ParameterTypes,
+			ReturnType:     n.ReturnType,
+			Body:           n.Body,
+			Env:            env,
+		}
+	case *ast.MacroNode:
+		return e.Errorf(n, "macros can only be defined by a top level let")
+	case *ast.CallNode:
+		if isCallTo(n, "quote") {
+			return e.Quote(n, env)
+		}
+		return e.Call(n, env)
+	case nil:
+		return NULL
+	default:
+		return e.Errorf(node, "cannot evaluate %T", node)
+	}
+}
+
+func (e *Evaluator) Root(node *ast.RootNode, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, stmt := range node.Statements {
+		result = e.Eval(stmt, env)
+
+		switch r := result.(type) {
+		case *object.ReturnValue:
+			return r.Value
+		case *object.Error:
+			return r
+		case *object.Break, *object.Continue:
+			return e.outsideLoop(r)
+		}
+	}
+
+	return result
+}
+
+func (e *Evaluator) Block(node *ast.BlockNode, env *object.Environment) object.Object {
+	var result object.Object = NULL
+
+	for _, stmt := range node.Statements {
+		result = e.Eval(stmt, env)
+
+		// Leave returns wrapped so they keep unwinding to the enclosing function,
+		// and breaks and continues to the enclosing loop.
+		if unwinds(result) {
+			return result
+		}
+	}
+
+	if result == nil {
+		return NULL
+	}
+
+	return result
+}
+
+func (e *Evaluator) Let(node *ast.LetNode, env *object.Environment) object.Object {
+	val := e.Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if node.Annotation != nil {
+		if err := e.Conform(val, node.Annotation, node.Value, node.Identifier.Value); err != nil {
+			return err
+		}
+	}
+
+	if fn, ok := val.(*object.Function); ok && len(fn.Name) == 0 {
+		fn.Name = node.Identifier.Value
+	}
+
+	env.Set(node.Identifier.Value, val)
+	return nil
+}
+
+func (e *Evaluator) Return(node *ast.ReturnNode, env *object.Environment) object.Object {
+	val := e.Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	return &object.ReturnValue{Value: val}
+}
+
+func (e *Evaluator) Identifier(node *ast.IdentifierNode, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+
+	return e.Errorf(node, "identifier not found: %s", node.Value)
+}
+
+func (e *Evaluator) Prefix(node *ast.PrefixNode, env *object.Environment) object.Object {
+	right := e.Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+
+	switch node.Operator {
+	case "!":
+		return nativeBoolean(!isTruthy(right))
+	case "-":
+		if i, ok := right.(*object.Integer); ok {
+			return &object.Integer{Value: -i.Value}
+		}
+	}
+
+	return e.Errorf(node, "unknown operator: %s%s", node.Operator, right.Type())
+}
+
+func (e *Evaluator) Infix(node *ast.InfixNode, env *object.Environment) object.Object {
+	left := e.Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	// The right of ?? is only evaluated when it is needed.
+	if node.Operator == "??" {
+		if left != NULL {
+			return left
+		}
+		return e.Eval(node.Right, env)
+	}
+
+	right := e.Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+
+	l, lok := left.(*object.Integer)
+	r, rok := right.(*object.Integer)
+
+	// Values of different types are never equal, so comparing them is not a mismatch.
+	switch {
+	case lok && rok:
+		return e.IntegerInfix(node, l.Value, r.Value)
+	case node.Operator == "==":
+		return nativeBoolean(left == right)
+	case node.Operator == "!=":
+		return nativeBoolean(left != right)
+	case left.Type() != right.Type():
+		return e.Errorf(node, "type mismatch: %s %s %s", left.Type(), node.Operator, right.Type())
+	default:
+		return e.Errorf(node, "unknown operator: %s %s %s", left.Type(), node.Operator, right.Type())
+	}
+}
+
+func (e *Evaluator) IntegerInfix(node *ast.InfixNode, left, right int64) object.Object {
+	switch node.Operator {
+	case "+":
+		return &object.Integer{Value: left + right}
+	case "-":
+		return &object.Integer{Value: left - right}
+	case "*":
+		return &object.Integer{Value: left * right}
+	case "/":
+		if right == 0 {
+			return e.Errorf(node, "division by zero")
+		}
+		return &object.Integer{Value: left / right}
+	case "<":
+		return nativeBoolean(left < right)
+	case ">":
+		return nativeBoolean(left > right)
+	case "==":
+		return nativeBoolean(left == right)
+	case "!=":
+		return nativeBoolean(left != right)
+	default:
+		return e.Errorf(node, "unknown operator: INTEGER %s INTEGER", node.Operator)
+	}
+}
+
+// Assign, rebinds a name where it is bound, giving the value assigned. A compound
+// assignment such as x += 1 assigns the result of x + 1.
+func (e *Evaluator) Assign(node *ast.AssignNode, env *object.Environment) object.Object {
+	var val object.Object
+	if node.Operator == "=" {
+		val = e.Eval(node.Value, env)
+	} else {
+		val = e.Infix(&ast.InfixNode{
+			Left:     node.Name,
+			Operator: strings.TrimSuffix(node.Operator, "="),
+			Right:    node.Value,
+			Span:     node.Span,
+		}, env)
+	}
+	if isError(val) {
+		return val
+	}
+
+	if !env.Assign(node.Name.Value, val) {
+		return e.Errorf(node.Name, "cannot assign to undefined name: %s", node.Name.Value)
+	}
+
+	return val
+}
+
+func (e *Evaluator) If(node *ast.IfNode, env *object.Environment) object.Object {
+	condition := e.Eval(node.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return e.Eval(node.Consequence, env)
+	} else if node.Alternative != nil {
+		return e.Eval(node.Alternative, env)
+	}
+
+	return NULL
+}
+
+// Conditional, evaluates the consequence if the condition is truthy and the alternative otherwise.
+func (e *Evaluator) Conditional(node *ast.ConditionalNode, env *object.Environment) object.Object {
+	condition := e.Eval(node.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return e.Eval(node.Consequence, env)
+	}
+
+	return e.Eval(node.Alternative, env)
+}
+
+// While, runs the body for as long as the condition is truthy. Like a let it produces no value.
+func (e *Evaluator) While(node *ast.WhileNode, env *object.Environment) object.Object {
+	for {
+		condition := e.Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		switch result := e.Eval(node.Body, env).(type) {
+		case *object.Break:
+			return nil
+		case *object.ReturnValue, *object.Error:
+			return result
+		}
+	}
+}
+
+// For, runs the body once for each item of the iterable. Each time round the body runs in
+// a new environment holding the loop variable, so closures made in it keep their own.
+func (e *Evaluator) For(node *ast.ForNode, env *object.Environment) object.Object {
+	iterable := e.Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	r, ok := iterable.(*object.Range)
+	if !ok {
+		return e.Errorf(node.Iterable, "cannot iterate over %s", iterable.Type())
+	}
+
+	for i := r.Start; i < r.End; i++ {
+		inner := object.NewEnclosedEnvironment(env)
+		inner.Set(node.Value.Value, &object.Integer{Value: i})
+
+		switch result := e.Eval(node.Body, inner).(type) {
+		case *object.Break:
+			return nil
+		case *object.ReturnValue, *object.Error:
+			return result
+		}
+	}
+
+	return nil
+}
+
+func (e *Evaluator) Range(node *ast.RangeNode, env *object.Environment) object.Object {
+	start := e.Eval(node.Start, env)
+	if isError(start) {
+		return start
+	}
+
+	end := e.Eval(node.End, env)
+	if isError(end) {
+		return end
+	}
+
+	s, sok := start.(*object.Integer)
+	t, tok := end.(*object.Integer)
+	if !sok || !tok {
+		return e.Errorf(node, "range bounds must be integers: %s..%s", start.Type(), end.Type())
+	}
+
+	return &object.Range{Start: s.Value, End: t.Value}
+}
+
+func (e *Evaluator) Call(node *ast.CallNode, env *object.Environment) object.Object {
+	function := e.Eval(node.Function, env)
+	if isError(function) {
+		return function
+	}
+
+	args := make([]object.Object, 0, len(node.Arguments))
+	for _, arg := range node.Arguments {
+		val := e.Eval(arg, env)
+		if isError(val) {
+			return val
+		}
+		args = append(args, val)
+	}
+
+	fn, ok := function.(*object.Function)
+	if !ok {
+		return e.Errorf(node, "not a function: %s", function.Type())
+	}
+
+	if len(args) != len(fn.Parameters) {
+		return e.Errorf(node, "wrong number of arguments: want %d, got %d", len(fn.Parameters), len(args))
+	}
+
+	if len(e.stack) >= MaxDepth {
+		return e.Errorf(node, "maximum call depth of %d exceeded", MaxDepth)
+	}
+
+	name := fn.Name
+	if len(name) == 0 {
+		name = "<anonymous>"
+	}
+
+	e.stack = append(e.stack, object.Frame{Function: name, Span: node.Span})
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+
+	inner := object.NewEnclosedEnvironment(fn.Env)
+	for i, param := range fn.Parameters {
+		if i < len(fn.ParameterTypes) && fn.ParameterTypes[i] != nil {
+			if err := e.Conform(args[i], fn.ParameterTypes[i], node.Arguments[i], param.Value); err != nil {
+				return err
+			}
+		}
+
+		inner.Set(param.Value, args[i])
+	}
+
+	result := e.Eval(fn.Body, inner)
+	switch r := result.(type) {
+	case *object.ReturnValue:
+		result = r.Value
+	case *object.Break, *object.Continue:
+		return e.outsideLoop(r)
+	}
+
+	if fn.ReturnType != nil && !isError(result) {
+		if err := e.Conform(result, fn.ReturnType, fn.ReturnType, "the result of "+name); err != nil {
+			return err
+		}
+	}
+
+	return result
+}
+
+// Errorf, creates an error located at node carrying the current call stack.
+func (e *Evaluator) Errorf(node ast.Node, format string, args ...any) *object.Error {
+	stack := slices.Clone(e.stack)
+	slices.Reverse(stack)
+
+	return &object.Error{
+		Message: fmt.Sprintf(format, args...),
+		Span:    node.Location(),
+		Stack:   stack,
+	}
+}
+
+// outsideLoop, returns the error for a break or continue that reached the edge of a function
+// or the program. The parser reports these, so only trees built some other way get here.
+func (e *Evaluator) outsideLoop(signal object.Object) *object.Error {
+	switch s := signal.(type) {
+	case *object.Break:
+		return e.Errorf(s.Node, "'break' outside of a loop")
+	case *object.Continue:
+		return e.Errorf(s.Node, "'continue' outside of a loop")
+	default:
+		panic(fmt.Sprintf("evaluator: %T is not a break or continue", signal))
+	}
+}
+
+// unwinds, returns true if obj must be passed up to the enclosing function or loop.
+func unwinds(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+
+	switch obj.Type() {
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	default:
+		return false
+	}
+}
+
+func nativeBoolean(value bool) *object.Boolean {
+	if value {
+		return TRUE
+	}
+
+	return FALSE
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
@@ -0,0 +1,182 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/object"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eval(t *testing.T, code string) object.Object {
+	t.Helper()
+
+	p := parser.New(token.NewTokenizer(code))
+	root := p.Parse()
+	require.Empty(t, p.Errors())
+
+	return Eval(root, object.NewEnvironment())
+}
+
+func Test_Eval(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected string
+	}{
+		{name: "integer", given: "5", expected: "5"},
+		{name: "negation", given: "-5", expected: "-5"},
+		{name: "arithmetic", given: "(5 + 10 * 2 + 15 / 3) * 2 + -10", expected: "50"},
+		{name: "comparison", given: "1 < 2 == true", expected: "true"},
+		{name: "boolean equality", given: "true != false", expected: "true"},
+		{name: "bang", given: "!!5", expected: "true"},
+		{name: "if", given: "if (1 > 2) { 10 } else { 20 }", expected: "20"},
+		{name: "if without else", given: "if (false) { 10 }", expected: "null"},
+		{name: "else if", given: "let sign = fn(x) { if (x < 0) { -1 } else if (x > 0) { 1 } else { 0 } }; sign(-5) * 100 + sign(5) * 10 + sign(0)", expected: "-90"},
+		{name: "else if without else", given: "if (false) { 1 } else if (false) { 2 }", expected: "null"},
+		{name: "conditional", given: "let abs = fn(x) { x < 0 ? -x : x }; abs(-3) + abs(4)", expected: "7"},
+		{name: "conditional chain", given: "let n = 15; n / 15 * 15 == n ? 3 : n / 5 * 5 == n ? 5 : 0", expected: "3"},
+		{name: "conditional evaluates one branch", given: "let n = 0; true ? n += 1 : (n += 10); n", expected: "1"},
+		{name: "return", given: "if (true) { if (true) { return 10; } return 1; }", expected: "10"},
+		{name: "let", given: "let a = 5; let b = a * 2; b + a;", expected: "15"},
+		{name: "null", given: "null", expected: "null"},
+		{name: "null is falsy", given: "if (null) { 1 } else { 2 }", expected: "2"},
+		{name: "null equality", given: "null == if (false) { 1 }", expected: "true"},
+		{name: "compare with null", given: "let x = 1; x == null", expected: "false"},
+		{name: "compare null with", given: "null != 1", expected: "true"},
+		{name: "coalesce null", given: "let a = if (false) { 1 }; a ?? 5", expected: "5"},
+		{name: "coalesce value", given: "let a = 0; a ?? 5", expected: "0"},
+		{name: "coalesce false", given: "false ?? true", expected: "false"},
+		{name: "coalesce chain", given: "null ?? null ?? 3", expected: "3"},
+		{name: "coalesce short circuits", given: "let n = 0; let f = fn() { n += 1 }; 1 ?? f(); null ?? f(); n", expected: "1"},
+		{name: "let has no value", given: "let a = 5;", expected: "<nil>"},
+		{name: "function", given: "let add = fn(x, y) { x + y; }; add(5, add(1, 1));", expected: "7"},
+		{name: "closure", given: "let adder = fn(x) { fn(y) { x + y } }; adder(2)(3);", expected: "5"},
+		{name: "recursion", given: "let f = fn(n) { if (n < 1) { 0 } else { n + f(n - 1) } }; f(100);", expected: "5050"},
+		{name: "annotations", given: "let add: fn(int, int) -> int = fn(x: int, y: int) -> int { x + y }; add(1, 2)", expected: "3"},
+		{name: "annotated value", given: "let a: bool = 1 < 2; a", expected: "true"},
+		{name: "annotated function value", given: "fn(x: int) -> bool { x > 1 }", expected: "fn(x: int) -> bool {\n\t(x > 1)\n}"},
+		{name: "function value", given: "fn(x) { x + 1; }", expected: "fn(x) {\n\t(x + 1)\n}"},
+		{name: "while", given: "let i = 0; let sum = 0; while (i < 100000) { let i = i + 1; let sum = sum + i; } sum", expected: "5000050000"},
+		{name: "while has no value", given: "while (false) { 1 }", expected: "<nil>"},
+		{name: "break", given: "let i = 0; while (true) { if (i > 4) { break; } let i = i + 1; } i", expected: "5"},
+		{name: "continue", given: "let i = 0; let odd = 0; while (i < 10) { let i = i + 1; if (i / 2 * 2 == i) { continue; } let odd = odd + 1; } odd", expected: "5"},
+		{name: "nested loops", given: "let i = 0; let n = 0; while (i < 3) { let i = i + 1; let j = 0; while (true) { let j = j + 1; let n = n + 1; if (j > 1) { break; } } } n", expected: "6"},
+		{name: "range", given: "let r: range = 1..4; r", expected: "1..4"},
+		{name: "for", given: "let root = fn(n) { for (i in 0..n + 1) { if (i * i > n) { return i - 1; } } }; root(50)", expected: "7"},
+		{name: "for break", given: "let f = fn() { for (i in 0..100) { if (i > 4) { return i; } } }; f()", expected: "5"},
+		{name: "for continue", given: "let f = fn() { for (i in 0..10) { if (i < 8) { continue; } return i; } }; f()", expected: "8"},
+		{name: "for empty range", given: "let f = fn() { for (i in 5..0) { return i; } 1 }; f()", expected: "1"},
+		{name: "closure in loop", given: "let f = fn() { for (i in 3..6) { if (i == 4) { return fn() { i }; } } }; f()()", expected: "4"},
+		{name: "assign", given: "let a = 1; a = a + 1; a", expected: "2"},
+		{name: "assign value", given: "let a = 1; let b = 1; a = b = 5; a + b", expected: "10"},
+		{name: "compound assign", given: "let a = 10; a += 5; a -= 3; a *= 2; a /= 4; a", expected: "6"},
+		{name: "assign in loop", given: "let sum = 0; for (i in 1..5) { sum += i; } sum", expected: "10"},
+		{name: "assign captured", given: "let counter = fn() { let n = 0; fn() { n += 1 } }; let next = counter(); next(); next()", expected: "2"},
+		{name: "assign outer from function", given: "let n = 1; let double = fn() { n *= 2; }; double(); double(); n", expected: "4"},
+		{name: "closure per iteration", given: "let f = fn() { 0 }; for (i in 0..3) { if (i == 1) { f = fn() { i }; } } f()", expected: "1"},
+		{name: "return from loop", given: "let f = fn() { let i = 0; while (true) { let i = i + 1; if (i > 2) { return i; } } }; f()", expected: "3"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := eval(t, tc.given)
+			if result == nil {
+				assert.Equal(t, tc.expected, "<nil>")
+				return
+			}
+			assert.Equal(t, tc.expected, result.Inspect())
+		})
+	}
+}
+
+func Test_Eval_Errors(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected string
+		failing  string
+	}{
+		{name: "type mismatch", given: "5 + true; 5;", expected: "type mismatch: INTEGER + BOOLEAN", failing: "5 + true"},
+		{name: "unknown prefix", given: "-true", expected: "unknown operator: -BOOLEAN", failing: "-true"},
+		{name: "unknown infix", given: "true + false", expected: "unknown operator: BOOLEAN + BOOLEAN", failing: "true + false"},
+		{name: "unbound identifier", given: "let a = 1; b", expected: "identifier not found: b", failing: "b"},
+		{name: "not a function", given: "let a = 1; a(2)", expected: "not a function: INTEGER", failing: "a(2)"},
+		{name: "arguments", given: "fn(x) { x }()", expected: "wrong number of arguments: want 1, got 0", failing: "fn(x) { x }()"},
+		{name: "division by zero", given: "10 / (5 - 5)", expected: "division by zero", failing: "10 / (5 - 5)"},
+		{name: "halts in condition", given: "if (1 + true) { 10 } else { 20 }", expected: "type mismatch: INTEGER + BOOLEAN", failing: "1 + true"},
+		{name: "halts in arguments", given: "let f = fn(x) { 1 }; f(-true)", expected: "unknown operator: -BOOLEAN", failing: "-true"},
+		{name: "annotated let", given: "let a: int = true;", expected: "type mismatch: a is int, got BOOLEAN", failing: "true"},
+		{name: "annotated parameter", given: "let f = fn(x: int) { x }; f(1 > 2)", expected: "type mismatch: x is int, got BOOLEAN", failing: "1 > 2"},
+		{name: "annotated result", given: "let f = fn(x) -> bool { x }; f(1)", expected: "type mismatch: the result of f is bool, got INTEGER", failing: "bool"},
+		{name: "annotated function", given: "let f: fn(int) -> int = 1;", expected: "type mismatch: f is fn(int) -> int, got INTEGER", failing: "1"},
+		{name: "unknown type", given: "let a: string = 1;", expected: "unknown type: string", failing: "string"},
+		{name: "iterate integer", given: "for (x in 5) { x }", expected: "cannot iterate over INTEGER", failing: "5"},
+		{name: "range bounds", given: "0..true", expected: "range bounds must be integers: INTEGER..BOOLEAN", failing: "0..true"},
+		{name: "loop variable scope", given: "for (i in 0..2) { 1 }; i", expected: "identifier not found: i", failing: "i"},
+		{name: "assign undefined", given: "x = 1", expected: "cannot assign to undefined name: x", failing: "x"},
+		{name: "compound assign undefined", given: "x += 1", expected: "identifier not found: x", failing: "x"},
+		{name: "compound assign mismatch", given: "let b = true; b += 1", expected: "type mismatch: BOOLEAN + INTEGER", failing: "b += 1"},
+		{name: "loop variable does not escape", given: "let f = fn() { for (i in 0..2) { 1 } i = 1 }; f()", expected: "cannot assign to undefined name: i", failing: "i"},
+		{name: "halts in conditional", given: "(1 + true) ? 1 : 2", expected: "type mismatch: INTEGER + BOOLEAN", failing: "1 + true"},
+		{name: "halts in loops", given: "while (true) { 1 + true; }", expected: "type mismatch: INTEGER + BOOLEAN", failing: "1 + true"},
+		{name: "halts in blocks", given: "if (true) { true + true; return 1; }", expected: "unknown operator: BOOLEAN + BOOLEAN", failing: "true + true"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := eval(t, tc.given)
+
+			err, ok := result.(*object.Error)
+			require.True(t, ok, "expected an error, got %v", result)
+			assert.Equal(t, tc.expected, err.Message)
+			assert.Equal(t, tc.failing, tc.given[err.Span.Start:err.Span.End])
+		})
+	}
+}
+
+func Test_Eval_OutsideLoop(t *testing.T) {
+	given := ast.Root(ast.Let(ast.Identifier("f"), ast.Function(ast.Block(ast.Break()))),
+		ast.ExpressionStatement(ast.Call(ast.Identifier("f"))))
+
+	err, ok := Eval(given, object.NewEnvironment()).(*object.Error)
+	require.True(t, ok)
+	assert.Equal(t, "'break' outside of a loop", err.Message)
+
+	err, ok = Eval(ast.Root(ast.Continue()), object.NewEnvironment()).(*object.Error)
+	require.True(t, ok)
+	assert.Equal(t, "'continue' outside of a loop", err.Message)
+}
+
+func Test_Eval_StackTrace(t *testing.T) {
+	given := `let inner = fn(x) { x + true };
+let outer = fn(x) { inner(x) };
+outer(1);`
+
+	result := eval(t, given)
+
+	err, ok := result.(*object.Error)
+	require.True(t, ok)
+
+	text := func(f object.Frame) string {
+		return given[f.Span.Start:f.Span.End]
+	}
+
+	require.Len(t, err.Stack, 2)
+	assert.Equal(t, "inner", err.Stack[0].Function)
+	assert.Equal(t, "inner(x)", text(err.Stack[0]))
+	assert.Equal(t, "outer", err.Stack[1].Function)
+	assert.Equal(t, "outer(1)", text(err.Stack[1]))
+}
+
+func Test_Eval_MaxDepth(t *testing.T) {
+	result := eval(t, "let f = fn() { f() }; f();")
+
+	err, ok := result.(*object.Error)
+	require.True(t, ok)
+	assert.Equal(t, "maximum call depth of 10000 exceeded", err.Message)
+	assert.Len(t, err.Stack, MaxDepth)
+}
@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/object"
+)
+
+// DefineMacros, removes every top level `let name = macro(...)` from root and binds it in env.
+func DefineMacros(root *ast.RootNode, env *object.Environment) {
+	statements := root.Statements[:0]
+
+	for _, stmt := range root.Statements {
+		let, ok := stmt.(*ast.LetNode)
+		if !ok {
+			statements = append(statements, stmt)
+			continue
+		}
+
+		macro, ok := let.Value.(*ast.MacroNode)
+		if !ok {
+			statements = append(statements, stmt)
+			continue
+		}
+
+		env.Set(let.Identifier.Value, &object.Macro{
+			Parameters: macro.Parameters,
+			Body:       macro.Body,
+			Env:        env,
+		})
+	}
+
+	root.Statements = statements
+}
+
+// ExpandMacros, replaces every call of a macro bound in env with the code the macro returns.
+// The macro is evaluated with its arguments quoted rather than evaluated.
+func ExpandMacros(root *ast.RootNode, env *object.Environment) (*ast.RootNode, *object.Error) {
+	var err *object.Error
+
+	e := New()
+
+	expanded := ast.Modify(root, func(n ast.Node) ast.Node {
+		call, ok := n.(*ast.CallNode)
+		if !ok || err != nil {
+			return n
+		}
+
+		macro, ok := macroOf(call, env)
+		if !ok {
+			return n
+		}
+
+		if len(call.Arguments) != len(macro.Parameters) {
+			err = e.Errorf(call, "wrong number of arguments: want %d, got %d", len(macro.Parameters), len(call.Arguments))
+			return n
+		}
+
+		inner := object.NewEnclosedEnvironment(macro.Env)
+		for i, param := range macro.Parameters {
+			inner.Set(param.Value, &object.Quote{Node: call.Arguments[i]})
+		}
+
+		result := e.Eval(macro.Body, inner)
+		if rv, ok := result.(*object.ReturnValue); ok {
+			result = rv.Value
+		}
+
+		switch result := result.(type) {
+		case *object.Error:
+			err = result
+			return n
+		case *object.Quote:
+			if expr, ok := result.Node.(ast.Expression); ok {
+				return expr
+			}
+			err = e.Errorf(call, "macro must return a quoted expression, got %T", result.Node)
+			return n
+		default:
+			err = e.Errorf(call, "macro must return a quoted expression, got %s", result.Type())
+			return n
+		}
+	})
+
+	if err != nil {
+		return root, err
+	}
+
+	return expanded.(*ast.RootNode), nil
+}
+
+func macroOf(call *ast.CallNode, env *object.Environment) (*object.Macro, bool) {
+	id, ok := call.Function.(*ast.IdentifierNode)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := env.Get(id.Value)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
@@ -0,0 +1,164 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/object"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Quote(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{given: "quote(5)", expected: "QUOTE(5)"},
+		{given: "quote(5 + 8)", expected: "QUOTE((5 + 8))"},
+		{given: "quote(foobar + barfoo)", expected: "QUOTE((foobar + barfoo))"},
+		{given: "quote(unquote(4 + 4))", expected: "QUOTE(8)"},
+		{given: "quote(8 + unquote(4 + 4))", expected: "QUOTE((8 + 8))"},
+		{given: "let foobar = 8; quote(unquote(foobar) + 1)", expected: "QUOTE((8 + 1))"},
+		{given: "quote(unquote(true == false))", expected: "QUOTE(false)"},
+		{given: "quote(unquote(quote(4 + 4)))", expected: "QUOTE((4 + 4))"},
+		{given: "quote(unquote(null) ?? 1)", expected: "QUOTE((null ?? 1))"},
+		{given: "quote(unquote(if (false) { 1 }))", expected: "QUOTE(null)"},
+		{given: "let q = quote(4 + 4); quote(unquote(4 + 4) + unquote(q))", expected: "QUOTE((8 + (4 + 4)))"},
+		{given: "let f = fn(x) { quote(unquote(x) * 2) }; f(1); f(3)", expected: "QUOTE((3 * 2))"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.given, func(t *testing.T) {
+			assert.Equal(t, tc.expected, eval(t, tc.given).Inspect())
+		})
+	}
+}
+
+func Test_Quote_Errors(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{given: "quote(1, 2)", expected: "wrong number of arguments to quote: want 1, got 2"},
+		{given: "quote(unquote())", expected: "wrong number of arguments to unquote: want 1, got 0"},
+		{given: "quote(unquote(1 + true))", expected: "type mismatch: INTEGER + BOOLEAN"},
+		{given: "quote(unquote(fn(x) { x }))", expected: "cannot unquote FUNCTION"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.given, func(t *testing.T) {
+			err, ok := eval(t, tc.given).(*object.Error)
+			require.True(t, ok)
+			assert.Equal(t, tc.expected, err.Message)
+		})
+	}
+}
+
+func parseProgram(t *testing.T, code string) *ast.RootNode {
+	t.Helper()
+
+	p := parser.New(token.NewTokenizer(code))
+	root := p.Parse()
+	require.Empty(t, p.Errors())
+
+	return root
+}
+
+func Test_DefineMacros(t *testing.T) {
+	root := parseProgram(t, `
+		let number = 1;
+		let function = fn(x, y) { x + y };
+		let mymacro = macro(x, y) { x + y; };
+	`)
+
+	env := object.NewEnvironment()
+	DefineMacros(root, env)
+
+	assert.Len(t, root.Statements, 2)
+
+	_, ok := env.Get("number")
+	assert.False(t, ok)
+
+	obj, ok := env.Get("mymacro")
+	require.True(t, ok)
+	macro, ok := obj.(*object.Macro)
+	require.True(t, ok)
+	assert.Equal(t, "macro(x, y) {\n\t(x + y)\n}", macro.Inspect())
+}
+
+func Test_ExpandMacros(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{
+			given:    "let infix = macro() { quote(1 + 2); }; infix();",
+			expected: "(1 + 2)",
+		},
+		{
+			given:    "let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); }; reverse(2 + 2, 10 - 5);",
+			expected: "((10 - 5) - (2 + 2))",
+		},
+		{
+			given: `
+				let unless = macro(condition, consequence, alternative) {
+					quote(if (!(unquote(condition))) {
+						unquote(consequence);
+					} else {
+						unquote(alternative);
+					});
+				};
+				unless(10 > 5, a, b);
+			`,
+			expected: "if (!(10 > 5)) {\n\ta\n} else {\n\tb\n}",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.expected, func(t *testing.T) {
+			root := parseProgram(t, tc.given)
+
+			env := object.NewEnvironment()
+			DefineMacros(root, env)
+			expanded, err := ExpandMacros(root, env)
+			require.Nil(t, err)
+
+			var buf bytes.Buffer
+			ast.NewWriter(&buf).Write(expanded)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func Test_ExpandMacros_Errors(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{given: "let m = macro(x) { 1 }; m(2);", expected: "macro must return a quoted expression, got INTEGER"},
+		{given: "let m = macro(x) { quote(x) }; m(1, 2);", expected: "wrong number of arguments: want 1, got 2"},
+		{given: "let m = macro() { 1 + true }; m();", expected: "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.given, func(t *testing.T) {
+			root := parseProgram(t, tc.given)
+
+			env := object.NewEnvironment()
+			DefineMacros(root, env)
+			_, err := ExpandMacros(root, env)
+			require.NotNil(t, err)
+			assert.Equal(t, tc.expected, err.Message)
+		})
+	}
+}
+
+func Test_Eval_MacroLiteral(t *testing.T) {
+	err, ok := eval(t, "let m = fn() { macro(x) { x } }; m()").(*object.Error)
+	require.True(t, ok)
+	assert.Equal(t, "macros can only be defined by a top level let", err.Message)
+}
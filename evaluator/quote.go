@@ -0,0 +1,72 @@
+package evaluator
+
+import (
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/object"
+	"github.com/maybe-joe/monkey/token"
+)
+
+// Quote, returns the argument of a call to quote without evaluating it,
+// except for calls to unquote inside it which are replaced by their evaluated argument.
+func (e *Evaluator) Quote(node *ast.CallNode, env *object.Environment) object.Object {
+	if len(node.Arguments) != 1 {
+		return e.Errorf(node, "wrong number of arguments to quote: want 1, got %d", len(node.Arguments))
+	}
+
+	var err object.Object
+
+	// The quoted code belongs to the tree being evaluated, so unquote a copy of it
+	// to leave the original intact for the next evaluation.
+	quoted := ast.Modify(ast.Clone(node.Arguments[0]), func(n ast.Node) ast.Node {
+		call, ok := n.(*ast.CallNode)
+		if !ok || err != nil || !isCallTo(call, "unquote") {
+			return n
+		}
+
+		if len(call.Arguments) != 1 {
+			err = e.Errorf(call, "wrong number of arguments to unquote: want 1, got %d", len(call.Arguments))
+			return n
+		}
+
+		val := e.Eval(call.Arguments[0], env)
+		if isError(val) {
+			err = val
+			return n
+		}
+
+		replacement := toNode(val, call.Span)
+		if replacement == nil {
+			err = e.Errorf(call, "cannot unquote %s", val.Type())
+			return n
+		}
+
+		return replacement
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return &object.Quote{Node: quoted}
+}
+
+// toNode, converts an evaluated value back into code, returning nil if it cannot be.
+func toNode(obj object.Object, span token.Span) ast.Node {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return &ast.IntegerNode{Value: obj.Value, Span: span}
+	case *object.Boolean:
+		return &ast.BooleanNode{Value: obj.Value, Span: span}
+	case *object.Null:
+		return &ast.NullNode{Span: span}
+	case *object.Quote:
+		return ast.Clone(obj.Node)
+	default:
+		return nil
+	}
+}
+
+func isCallTo(call *ast.CallNode, name string) bool {
+	id, ok := call.Function.(*ast.IdentifierNode)
+	return ok && id.Value == name
+}
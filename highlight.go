@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/maybe-joe/monkey/highlight"
+)
+
+func highlightSource(args []string) error {
+	flags := flag.NewFlagSet("highlight", flag.ContinueOnError)
+	asHTML := flags.Bool("html", false, "render the source as html spans instead of terminal colours")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	src, err := readSource(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *asHTML {
+		fmt.Print(`<pre class="monkey">`)
+		highlight.Source(os.Stdout, src.Code, highlight.HTML{})
+		fmt.Print("</pre>\n")
+		return nil
+	}
+
+	highlight.Source(os.Stdout, src.Code, highlight.For(os.Stdout))
+	return nil
+}
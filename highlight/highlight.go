@@ -0,0 +1,162 @@
+package highlight
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+
+	"github.com/maybe-joe/monkey/token"
+)
+
+// Class, the kind of highlighting applied to a piece of source.
+type Class int
+
+const (
+	Plain Class = iota
+	Keyword
+	Identifier
+	Literal
+	Operator
+	Delimiter
+	Illegal
+	Comment
+)
+
+var classNames = map[Class]string{
+	Plain:      "plain",
+	Keyword:    "keyword",
+	Identifier: "identifier",
+	Literal:    "literal",
+	Operator:   "operator",
+	Delimiter:  "delimiter",
+	Illegal:    "illegal",
+	Comment:    "comment",
+}
+
+func (c Class) String() string {
+	return classNames[c]
+}
+
+// Classify, returns the highlighting class for a token type.
+func Classify(typ token.TokenType) Class {
+	switch typ {
+	case token.FUNCTION, token.LET, token.IF, token.ELSE, token.RETURN, token.MACRO,
+		token.WHILE, token.BREAK, token.CONTINUE, token.FOR, token.IN:
+		return Keyword
+	case token.IDENT:
+		return Identifier
+	case token.INT, token.TRUE, token.FALSE, token.NULL:
+		return Literal
+	case token.ASSIGN, token.PLUS, token.MINUS, token.BANG, token.ASTERISK, token.SLASH,
+		token.LT, token.GT, token.EQ, token.NOT_EQ, token.ARROW, token.DOTDOT, token.COALESCE, token.QUESTION,
+		token.PLUS_ASSIGN, token.MINUS_ASSIGN, token.ASTERISK_ASSIGN, token.SLASH_ASSIGN:
+		return Operator
+	case token.COMMA, token.SEMICOLON, token.COLON, token.LPAREN, token.RPAREN, token.LBRACE, token.RBRACE:
+		return Delimiter
+	case token.ILLEGAL:
+		return Illegal
+	default:
+		return Plain
+	}
+}
+
+// Formatter, decorates a piece of text according to its class.
+type Formatter interface {
+	Format(w io.Writer, class Class, text string)
+}
+
+// Text, writes text without any decoration.
+type Text struct{}
+
+func (Text) Format(w io.Writer, _ Class, text string) {
+	io.WriteString(w, text)
+}
+
+// ANSI, wraps text in terminal colour escape codes.
+type ANSI struct{}
+
+var colours = map[Class]string{
+	Keyword:    "\x1b[35m",
+	Identifier: "\x1b[36m",
+	Literal:    "\x1b[33m",
+	Operator:   "\x1b[34m",
+	Illegal:    "\x1b[31;4m",
+	Comment:    "\x1b[90m",
+}
+
+const reset = "\x1b[0m"
+
+func (ANSI) Format(w io.Writer, class Class, text string) {
+	colour, ok := colours[class]
+	if !ok || len(text) == 0 {
+		io.WriteString(w, text)
+		return
+	}
+
+	fmt.Fprintf(w, "%s%s%s", colour, text, reset)
+}
+
+// HTML, escapes text and wraps it in a span with a "mk-<class>" css class.
+type HTML struct{}
+
+func (HTML) Format(w io.Writer, class Class, text string) {
+	if class == Plain {
+		io.WriteString(w, html.EscapeString(text))
+		return
+	}
+
+	fmt.Fprintf(w, `<span class="mk-%s">%s</span>`, class, html.EscapeString(text))
+}
+
+// For, returns ANSI if w is a terminal and Text otherwise.
+func For(w io.Writer) Formatter {
+	if IsTerminal(w) {
+		return ANSI{}
+	}
+
+	return Text{}
+}
+
+// IsTerminal, returns true if w is a file attached to a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Source, writes code to w with every token and comment formatted by f.
+// The text between them is written as Plain so the output reads the same as code.
+func Source(w io.Writer, code string, f Formatter) {
+	tz := token.NewTokenizer(code)
+	prev, comments := 0, 0
+
+	// gap, writes the code up to end, formatting the comments skipped over on the way.
+	gap := func(end int) {
+		for ; comments < len(tz.Comments()); comments++ {
+			c := tz.Comments()[comments]
+			f.Format(w, Plain, code[prev:c.Span.Start])
+			f.Format(w, Comment, code[c.Span.Start:c.Span.End])
+			prev = c.Span.End
+		}
+		f.Format(w, Plain, code[prev:end])
+		prev = end
+	}
+
+	for t := tz.Next(); !t.Is(token.EOF); t = tz.Next() {
+		span := tz.Span()
+		gap(span.Start)
+		f.Format(w, Classify(t.Type), code[span.Start:span.End])
+		prev = span.End
+	}
+
+	gap(len(code))
+}
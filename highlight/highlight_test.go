@@ -0,0 +1,68 @@
+package highlight
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Classify(t *testing.T) {
+	testcases := []struct {
+		given    token.TokenType
+		expected Class
+	}{
+		{token.LET, Keyword},
+		{token.FUNCTION, Keyword},
+		{token.IDENT, Identifier},
+		{token.INT, Literal},
+		{token.TRUE, Literal},
+		{token.NOT_EQ, Operator},
+		{token.LBRACE, Delimiter},
+		{token.ILLEGAL, Illegal},
+		{token.EOF, Plain},
+	}
+
+	for _, tc := range testcases {
+		t.Run(string(tc.given), func(t *testing.T) {
+			assert.Equal(t, tc.expected, Classify(tc.given))
+		})
+	}
+}
+
+func Test_Source_Text(t *testing.T) {
+	const code = "let  x = fn(a) {\n\treturn a != 1;\n};\n"
+
+	var buf bytes.Buffer
+	Source(&buf, code, Text{})
+	assert.Equal(t, code, buf.String())
+}
+
+func Test_Source_ANSI(t *testing.T) {
+	var buf bytes.Buffer
+	Source(&buf, "let x = 5;", ANSI{})
+	assert.Equal(t, "\x1b[35mlet\x1b[0m \x1b[36mx\x1b[0m \x1b[34m=\x1b[0m \x1b[33m5\x1b[0m;", buf.String())
+}
+
+func Test_Source_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	Source(&buf, "a < 1 @", HTML{})
+
+	expected := `<span class="mk-identifier">a</span> <span class="mk-operator">&lt;</span> ` +
+		`<span class="mk-literal">1</span> <span class="mk-illegal">@</span>`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_Source_Comments(t *testing.T) {
+	var buf bytes.Buffer
+	Source(&buf, "// one\na; // two", HTML{})
+
+	expected := `<span class="mk-comment">// one</span>` + "\n" + `<span class="mk-identifier">a</span><span class="mk-delimiter">;</span>` +
+		` <span class="mk-comment">// two</span>`
+	assert.Equal(t, expected, buf.String())
+}
+
+func Test_For(t *testing.T) {
+	assert.Equal(t, Text{}, For(&bytes.Buffer{}))
+}
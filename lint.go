@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+
+	"github.com/maybe-joe/monkey/lint"
+)
+
+func lintSource(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	format := flags.String("diagnostics", "text", "how to report problems, text or json")
+	path := flags.String("config", "", "the config file choosing the rules, "+lint.ConfigFile+" if it exists")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := lintConfig(*path)
+	if err != nil {
+		return err
+	}
+
+	src, err := readSource(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return report(src, lint.New(config).Lint(src), *format)
+}
+
+// lintConfig, loads the config at path, or the default config file when path is empty.
+func lintConfig(path string) (lint.Config, error) {
+	if path != "" {
+		return lint.LoadConfig(path)
+	}
+
+	config, err := lint.LoadConfig(lint.ConfigFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lint.Config{}, nil
+	}
+
+	return config, err
+}
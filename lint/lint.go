@@ -0,0 +1,188 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/resolver"
+	"github.com/maybe-joe/monkey/token"
+)
+
+// ConfigFile, the name of the config file looked for when none is given.
+const ConfigFile = ".monkeylint.json"
+
+// Config, chooses the rules to run. Rules are enabled unless the config turns them off.
+//
+//	{"rules": {"shadow": false}}
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// Enabled, returns true if the named rule should run.
+func (c Config) Enabled(rule string) bool {
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+// LoadConfig, reads the config in the file at path.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for name := range c.Rules {
+		if _, ok := Find(name); !ok {
+			return c, fmt.Errorf("%s: unknown rule %q", path, name)
+		}
+	}
+
+	return c, nil
+}
+
+// Rule, a check for a kind of mistake or poor style.
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(*Pass)
+}
+
+// Find, returns the rule with the given name.
+func Find(name string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Pass, what a rule is given to check a program.
+type Pass struct {
+	Root     *ast.RootNode
+	Resolver *resolver.Resolver
+
+	rule        Rule
+	diagnostics []diagnostics.Diagnostic
+}
+
+// Report, records a problem found by the rule being run.
+func (p *Pass) Report(d diagnostics.Diagnostic) {
+	p.diagnostics = append(p.diagnostics, d.WithNote(fmt.Sprintf("found by %s, silence with // lint:ignore %s", p.rule.Name, p.rule.Name)))
+}
+
+// Linter, runs the enabled rules over programs.
+type Linter struct {
+	Config Config
+}
+
+func New(config Config) *Linter {
+	return &Linter{Config: config}
+}
+
+// Lint, parses the code in src and checks it, returning the problems found in the order they occur.
+// Code that does not parse is not checked, the parse errors are returned instead.
+//
+// A problem is silenced by a comment on the line it starts or the line before:
+//
+//	// lint:ignore shadow, bool-compare
+//	let x = y == true;
+//
+// A comment naming no rules silences all of them.
+func (l *Linter) Lint(src *diagnostics.Source) []diagnostics.Diagnostic {
+	tz := token.NewTokenizer(src.Code)
+	p := parser.New(tz)
+
+	root := p.Parse()
+	if len(p.Diagnostics()) > 0 {
+		return p.Diagnostics()
+	}
+
+	r := resolver.New()
+	r.Resolve(root)
+
+	pass := &Pass{Root: root, Resolver: r}
+	ignored := suppressions(src, tz.Comments())
+
+	var result []diagnostics.Diagnostic
+	for _, rule := range Rules {
+		if !l.Config.Enabled(rule.Name) {
+			continue
+		}
+
+		pass.rule, pass.diagnostics = rule, nil
+		rule.Check(pass)
+
+		for _, d := range pass.diagnostics {
+			span, _ := d.Primary()
+			if rules, ok := ignored[src.Position(span.Start).Line]; ok && (len(rules) == 0 || rules[rule.Name]) {
+				continue
+			}
+
+			result = append(result, d)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, _ := result[i].Primary()
+		b, _ := result[j].Primary()
+		return a.Start < b.Start
+	})
+
+	return result
+}
+
+// suppressions, returns the rules silenced on each line by lint:ignore comments,
+// an empty set silences every rule.
+func suppressions(src *diagnostics.Source, comments []token.Comment) map[int]map[string]bool {
+	result := map[int]map[string]bool{}
+
+	for _, c := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+
+		rest, ok := strings.CutPrefix(text, "lint:ignore")
+		if !ok || (rest != "" && rest[0] != ' ') {
+			continue
+		}
+
+		rules := map[string]bool{}
+		for _, name := range strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' }) {
+			rules[name] = true
+		}
+
+		line := src.Position(c.Span.Start).Line
+		for _, l := range []int{line, line + 1} {
+			existing, ok := result[l]
+			switch {
+			case !ok:
+				result[l] = maps.Clone(rules)
+			case len(existing) == 0:
+			case len(rules) == 0:
+				result[l] = maps.Clone(rules)
+			default:
+				for name := range rules {
+					existing[name] = true
+				}
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,114 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lint(config Config, code string) []string {
+	var result []string
+	for _, d := range New(config).Lint(diagnostics.NewSource("test", code)) {
+		result = append(result, d.Message)
+	}
+
+	return result
+}
+
+func Test_Rules(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected []string
+	}{
+		{name: "clean", given: "let f = fn(x) { if (x > 1) { return x; } 0 }; f(1)", expected: nil},
+		{name: "shadow let", given: "let a = 1; let f = fn() { let a = 2; a }; f() + a", expected: []string{"'a' shadows an outer binding"}},
+		{name: "shadow parameter", given: "let a = 1; let f = fn(a) { a }; f(a)", expected: []string{"'a' shadows an outer binding"}},
+		{name: "shadow loop variable", given: "let i = 1; for (i in 0..i) { i }", expected: []string{"'i' shadows an outer binding"}},
+		{name: "rebinding is not shadowing", given: "let a = 1; let a = a + 1; a", expected: nil},
+		{name: "unused parameter", given: "let f = fn(a, b) { a }; f(1, 2)", expected: []string{"unused parameter 'b'"}},
+		{name: "unused parameter used by closure", given: "let f = fn(a) { fn() { a } }; f(1)", expected: nil},
+		{name: "bool compare", given: "let f = fn(a) { if (a == true) { 1 } else { 2 } }; f(true)", expected: []string{"comparison with true"}},
+		{name: "bool compare left", given: "let a = 1; false != a", expected: []string{"comparison with false"}},
+		{name: "empty block", given: "let f = fn() { }; f()", expected: []string{"empty block"}},
+		{name: "self assign", given: "let f = fn(a) { let a = a; a }; f(1)", expected: []string{"'a' is bound to itself"}},
+		{name: "self assignment", given: "let f = fn(a) { a = a; a += a }; f(1)", expected: []string{"'a' is assigned to itself"}},
+		{name: "missing return", given: "let f = fn(a) { if (a) { return 1; } let b = 2; }; f(1)", expected: []string{"function does not return a value on every path"}},
+		{name: "missing else", given: "let f = fn(a) { if (a) { return 1; } }; f(1)", expected: []string{"function does not return a value on every path"}},
+		{name: "implicit return", given: "let f = fn(a) { if (a) { return 1; } 2 }; f(1)", expected: nil},
+		{name: "returns in both branches", given: "let f = fn(a) { if (a) { return 1; } else { return 2; } }; f(1)", expected: nil},
+		{name: "unreachable", given: "let f = fn() { return 1; 2 }; f()", expected: []string{"unreachable code"}},
+		{name: "unreachable after break", given: "while (true) { break; 1 }", expected: []string{"unreachable code"}},
+		{name: "unreachable after continue", given: "let i = 0; while (i < 3) { let i = i + 1; if (true) { continue; } else { break; } i }", expected: []string{"unreachable code"}},
+		{name: "unreachable after if", given: "let f = fn(a) { if (a) { return 1; } else { return 2; } 3 }; f(1)", expected: []string{"unreachable code"}},
+		{name: "sorted", given: "let f = fn(a, b) { a == true }; f(1, 2)", expected: []string{"unused parameter 'b'", "comparison with true"}},
+		{name: "parse errors", given: "let = 1;", expected: []string{"expected identifier after let, found '='"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, lint(Config{}, tc.given))
+		})
+	}
+}
+
+func Test_Config(t *testing.T) {
+	const code = "let a = 1; let f = fn(a) { a == true }; f(a)"
+
+	assert.Equal(t, []string{"'a' shadows an outer binding", "comparison with true"}, lint(Config{}, code))
+	assert.Equal(t, []string{"comparison with true"}, lint(Config{Rules: map[string]bool{"shadow": false}}, code))
+	assert.Equal(t, []string{"comparison with true"}, lint(Config{Rules: map[string]bool{"shadow": false, "bool-compare": true}}, code))
+}
+
+func Test_LoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, ConfigFile)
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": {"shadow": false}}`), 0o644))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.False(t, config.Enabled("shadow"))
+	assert.True(t, config.Enabled("empty-block"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": {"shadows": false}}`), 0o644))
+	_, err = LoadConfig(path)
+	assert.EqualError(t, err, path+`: unknown rule "shadows"`)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"rule": {}}`), 0o644))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, `unknown field "rule"`)
+}
+
+func Test_Suppression(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected []string
+	}{
+		{name: "same line", given: "let a = 1;\na == true; // lint:ignore bool-compare", expected: nil},
+		{name: "line before", given: "let a = 1;\n// lint:ignore bool-compare\na == true;", expected: nil},
+		{name: "all rules", given: "let a = 1;\n// lint:ignore\na == true;", expected: nil},
+		{name: "other rule", given: "let a = 1;\n// lint:ignore shadow\na == true;", expected: []string{"comparison with true"}},
+		{name: "several rules", given: "let a = 1;\n// lint:ignore shadow, bool-compare\na == true;", expected: nil},
+		{name: "only nearby lines", given: "let a = 1; // lint:ignore\n\na == true;", expected: []string{"comparison with true"}},
+		{name: "not a directive", given: "let a = 1;\n// lint:ignored\na == true;", expected: []string{"comparison with true"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, lint(Config{}, tc.given))
+		})
+	}
+}
+
+func Test_Report_Note(t *testing.T) {
+	diags := New(Config{}).Lint(diagnostics.NewSource("test", "fn() { }"))
+	require.Len(t, diags, 1)
+	assert.Equal(t, []string{"found by empty-block, silence with // lint:ignore empty-block"}, diags[0].Notes)
+	assert.Equal(t, diagnostics.Warning, diags[0].Severity)
+}
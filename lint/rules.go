@@ -0,0 +1,288 @@
+package lint
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/token"
+)
+
+// Rules, every rule the linter knows, in the order they run.
+var Rules = []Rule{
+	{Name: "shadow", Description: "a let, parameter or loop variable hides an enclosing binding", Check: shadow},
+	{Name: "unused-param", Description: "a function parameter is never used", Check: unusedParam},
+	{Name: "bool-compare", Description: "a value is compared with true or false", Check: boolCompare},
+	{Name: "empty-block", Description: "a block has no statements", Check: emptyBlock},
+	{Name: "self-assign", Description: "a let or an assignment binds a name to itself", Check: selfAssign},
+	{Name: "missing-return", Description: "a function returns a value on some paths but not others", Check: missingReturn},
+	{Name: "unreachable", Description: "code follows a return, break or continue", Check: unreachable},
+}
+
+func shadow(p *Pass) {
+	scopes := []map[string]token.Span{{}}
+
+	bind := func(id *ast.IdentifierNode) {
+		if id == nil {
+			return
+		}
+
+		for i := len(scopes) - 2; i >= 0; i-- {
+			if outer, ok := scopes[i][id.Value]; ok {
+				p.Report(diagnostics.Warningf(id.Span, "'%s' shadows an outer binding", id.Value).
+					WithMessage("shadows").
+					WithLabel(outer, "outer binding"),
+				)
+				break
+			}
+		}
+
+		scopes[len(scopes)-1][id.Value] = id.Span
+	}
+
+	var visit func(node ast.Node)
+	function := func(params []*ast.IdentifierNode, body *ast.BlockNode) {
+		scopes = append(scopes, map[string]token.Span{})
+		for _, param := range params {
+			bind(param)
+		}
+		if body != nil {
+			visit(body)
+		}
+		scopes = scopes[:len(scopes)-1]
+	}
+
+	visit = func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.FunctionNode:
+			function(n.Parameters, n.Body)
+			return
+		case *ast.MacroNode:
+			function(n.Parameters, n.Body)
+			return
+		case *ast.LetNode:
+			if n.Value != nil {
+				visit(n.Value)
+			}
+			bind(n.Identifier)
+			return
+		case *ast.ForNode:
+			if n.Iterable != nil {
+				visit(n.Iterable)
+			}
+			// The body runs in an environment of its own holding the loop variable.
+			scopes = append(scopes, map[string]token.Span{})
+			bind(n.Value)
+			if n.Body != nil {
+				visit(n.Body)
+			}
+			scopes = scopes[:len(scopes)-1]
+			return
+		}
+
+		for _, child := range ast.Children(node) {
+			visit(child)
+		}
+	}
+
+	visit(p.Root)
+}
+
+func unusedParam(p *Pass) {
+	ast.Inspect(p.Root, func(node ast.Node) bool {
+		fn, ok := node.(*ast.FunctionNode)
+		if !ok {
+			return true
+		}
+
+		for _, param := range fn.Parameters {
+			s, ok := p.Resolver.Symbol(param)
+			if !ok || p.Resolver.Used(s) || strings.HasPrefix(param.Value, "_") {
+				continue
+			}
+
+			p.Report(diagnostics.Warningf(param.Span, "unused parameter '%s'", param.Value).
+				WithMessage("never used").
+				WithHint("start the name with an underscore if this is intended"),
+			)
+		}
+
+		return true
+	})
+}
+
+func boolCompare(p *Pass) {
+	ast.Inspect(p.Root, func(node ast.Node) bool {
+		n, ok := node.(*ast.InfixNode)
+		if !ok || (n.Operator != "==" && n.Operator != "!=") {
+			return true
+		}
+
+		literal, ok := n.Right.(*ast.BooleanNode)
+		if !ok {
+			if literal, ok = n.Left.(*ast.BooleanNode); !ok {
+				return true
+			}
+		}
+
+		hint := "use the value itself"
+		if literal.Value == (n.Operator == "!=") {
+			hint = "negate the value with !"
+		}
+
+		p.Report(diagnostics.Warningf(n.Span, "comparison with %t", literal.Value).WithHint(hint))
+		return true
+	})
+}
+
+func emptyBlock(p *Pass) {
+	ast.Inspect(p.Root, func(node ast.Node) bool {
+		if block, ok := node.(*ast.BlockNode); ok && len(block.Statements) == 0 {
+			p.Report(diagnostics.Warningf(block.Span, "empty block"))
+		}
+
+		return true
+	})
+}
+
+func selfAssign(p *Pass) {
+	ast.Inspect(p.Root, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetNode:
+			if value, ok := n.Value.(*ast.IdentifierNode); ok && n.Identifier != nil && value.Value == n.Identifier.Value {
+				p.Report(diagnostics.Warningf(n.Span, "'%s' is bound to itself", value.Value))
+			}
+		case *ast.AssignNode:
+			if value, ok := n.Value.(*ast.IdentifierNode); ok && n.Operator == "=" && value.Value == n.Name.Value {
+				p.Report(diagnostics.Warningf(n.Span, "'%s' is assigned to itself", value.Value))
+			}
+		}
+
+		return true
+	})
+}
+
+func missingReturn(p *Pass) {
+	ast.Inspect(p.Root, func(node ast.Node) bool {
+		fn, ok := node.(*ast.FunctionNode)
+		if !ok || fn.Body == nil || !containsReturn(fn.Body) || producesValue(fn.Body) {
+			return true
+		}
+
+		p.Report(diagnostics.Warningf(fn.Span, "function does not return a value on every path").
+			WithMessage("returns on some paths only").
+			WithHint("add a return, or an expression, at the end of each path"),
+		)
+		return true
+	})
+}
+
+// containsReturn, returns true if there is a return in block outside of any function within it.
+func containsReturn(block *ast.BlockNode) bool {
+	found := false
+
+	ast.Inspect(block, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.FunctionNode, *ast.MacroNode:
+			return false
+		case *ast.ReturnNode:
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// producesValue, returns true if every path through block ends in a return or an expression.
+func producesValue(block *ast.BlockNode) bool {
+	if block == nil || len(block.Statements) == 0 {
+		return false
+	}
+
+	for _, stmt := range block.Statements {
+		if alwaysReturns(stmt) {
+			return true
+		}
+	}
+
+	switch last := block.Statements[len(block.Statements)-1].(type) {
+	case *ast.ExpressionStatementNode:
+		if n, ok := last.Expression.(*ast.IfNode); ok {
+			return producesValue(n.Consequence) && producesValue(n.Alternative)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// alwaysReturns, returns true if stmt returns on every path through it.
+func alwaysReturns(stmt ast.Statement) bool {
+	switch n := stmt.(type) {
+	case *ast.ReturnNode:
+		return true
+	case *ast.ExpressionStatementNode:
+		branch, ok := n.Expression.(*ast.IfNode)
+		return ok && blockReturns(branch.Consequence) && blockReturns(branch.Alternative)
+	default:
+		return false
+	}
+}
+
+func blockReturns(block *ast.BlockNode) bool {
+	if block == nil {
+		return false
+	}
+
+	for _, stmt := range block.Statements {
+		if alwaysReturns(stmt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// alwaysJumps, returns true if stmt returns, breaks or continues on every path through it.
+func alwaysJumps(stmt ast.Statement) bool {
+	switch n := stmt.(type) {
+	case *ast.ReturnNode, *ast.BreakNode, *ast.ContinueNode:
+		return true
+	case *ast.ExpressionStatementNode:
+		branch, ok := n.Expression.(*ast.IfNode)
+		return ok && blockJumps(branch.Consequence) && blockJumps(branch.Alternative)
+	default:
+		return false
+	}
+}
+
+func blockJumps(block *ast.BlockNode) bool {
+	return block != nil && slices.ContainsFunc(block.Statements, alwaysJumps)
+}
+
+func unreachable(p *Pass) {
+	check := func(stmts []ast.Statement) {
+		for i, stmt := range stmts[:max(len(stmts)-1, 0)] {
+			if alwaysJumps(stmt) {
+				p.Report(diagnostics.Warningf(stmts[i+1].Location(), "unreachable code").
+					WithLabel(stmt.Location(), "any code after this is unreachable"),
+				)
+				return
+			}
+		}
+	}
+
+	ast.Inspect(p.Root, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.RootNode:
+			check(n.Statements)
+		case *ast.BlockNode:
+			check(n.Statements)
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/maybe-joe/monkey/lsp"
+)
+
+// languageServer, answers an editor speaking the language server protocol over standard input and output.
+func languageServer(args []string) error {
+	flags := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return lsp.New(os.Stdin, os.Stdout).Run()
+}
@@ -0,0 +1,410 @@
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/resolver"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/maybe-joe/monkey/types"
+)
+
+// Keywords, offered by completion wherever the cursor is.
+var Keywords = []string{"fn", "let", "if", "else", "return", "true", "false", "null", "macro", "while", "break", "continue", "for", "in"}
+
+// binding, a name bound by a let or a parameter.
+type binding struct {
+	id   *ast.IdentifierNode
+	kind string
+	// scope, the function or macro the name is bound in, the root for the top level.
+	scope ast.Node
+	// value, the value bound by a let, nil for parameters.
+	value ast.Expression
+}
+
+// Document, an open document and what is known about its code.
+//
+// The code is kept parsed as it changes, each change reparsing only the statements
+// around it. The resolver and checker only run on code that parses, so while a
+// document has syntax errors hover, definitions and references find nothing.
+type Document struct {
+	URI     string
+	Version int
+	Source  *diagnostics.Source
+	Root    *ast.RootNode
+	// Comments, the comments in the code, which the tree does not hold.
+	Comments []token.Comment
+	// Syntax, the problems found parsing the code.
+	Syntax []diagnostics.Diagnostic
+
+	parsed   *parser.Document
+	resolver *resolver.Resolver
+	checker  *types.Checker
+	bindings []*binding
+	bound    map[*ast.IdentifierNode]*binding
+}
+
+// NewDocument, parses and analyses text.
+func NewDocument(uri string, version int, text string) *Document {
+	d := &Document{URI: uri, Version: version, parsed: parser.NewDocument(text)}
+	d.analyse()
+
+	return d
+}
+
+// Change, makes the changes to the code in order, then analyses the result.
+func (d *Document) Change(version int, changes []TextDocumentContentChangeEvent) {
+	for _, change := range changes {
+		span := token.Span{Start: 0, End: len(d.Source.Code)}
+		if change.Range != nil {
+			span = token.Span{Start: d.Offset(change.Range.Start), End: d.Offset(change.Range.End)}
+		}
+
+		d.parsed.Edit(parser.Edit{Span: span, Text: change.Text})
+		d.Source = diagnostics.NewSource(d.URI, d.parsed.Code())
+	}
+
+	d.Version = version
+	d.analyse()
+}
+
+func (d *Document) analyse() {
+	d.Source = diagnostics.NewSource(d.URI, d.parsed.Code())
+	d.Root = d.parsed.Root()
+	d.Comments = d.parsed.Comments()
+	d.Syntax = d.parsed.Diagnostics()
+
+	d.bindings, d.bound = nil, map[*ast.IdentifierNode]*binding{}
+	d.bind()
+
+	d.resolver, d.checker = nil, nil
+	if len(d.Syntax) == 0 {
+		d.resolver = resolver.New()
+		d.resolver.Resolve(d.Root)
+
+		d.checker = types.New()
+		d.checker.Check(d.Root)
+	}
+}
+
+// bind, finds the names bound in the tree and the scopes they are bound in.
+func (d *Document) bind() {
+	var stack []ast.Node
+	scope := func() ast.Node {
+		for i := len(stack) - 1; i >= 0; i-- {
+			switch stack[i].(type) {
+			case *ast.FunctionNode, *ast.MacroNode, *ast.ForNode:
+				return stack[i]
+			}
+		}
+		return d.Root
+	}
+
+	add := func(b *binding) {
+		if b.id != nil {
+			d.bindings = append(d.bindings, b)
+			d.bound[b.id] = b
+		}
+	}
+
+	ast.Inspect(d.Root, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.LetNode:
+			add(&binding{id: n.Identifier, kind: "let", scope: scope(), value: n.Value})
+		case *ast.FunctionNode:
+			for _, param := range n.Parameters {
+				add(&binding{id: param, kind: "parameter", scope: n})
+			}
+		case *ast.MacroNode:
+			for _, param := range n.Parameters {
+				add(&binding{id: param, kind: "parameter", scope: n})
+			}
+		case *ast.ForNode:
+			add(&binding{id: n.Value, kind: "loop variable", scope: n})
+		}
+
+		stack = append(stack, n)
+		return true
+	})
+}
+
+// Diagnostics, the problems in the code. Type errors are left out of programs that
+// define macros, as the checker sees the code before the macros are expanded.
+func (d *Document) Diagnostics() []diagnostics.Diagnostic {
+	if d.resolver == nil {
+		return d.Syntax
+	}
+
+	diags := slices.Clone(d.resolver.Diagnostics())
+	if !definesMacros(d.Root) {
+		diags = append(diags, d.checker.Diagnostics()...)
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, _ := diags[i].Primary()
+		b, _ := diags[j].Primary()
+		return a.Start < b.Start
+	})
+
+	return diags
+}
+
+func definesMacros(root *ast.RootNode) bool {
+	found := false
+	ast.Inspect(root, func(n ast.Node) bool {
+		if _, ok := n.(*ast.MacroNode); ok {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
+
+// Position, converts an offset into the code to a protocol position.
+func (d *Document) Position(offset int) Position {
+	p := d.Source.Position(offset)
+	line := d.Source.Line(p.Line)
+
+	return Position{
+		Line:      p.Line - 1,
+		Character: len(utf16.Encode([]rune(line[:min(p.Column-1, len(line))]))),
+	}
+}
+
+// Offset, converts a protocol position to an offset into the code, clamped to the code.
+func (d *Document) Offset(pos Position) int {
+	if last := d.Source.Position(len(d.Source.Code)).Line; pos.Line >= last {
+		return len(d.Source.Code)
+	}
+
+	line := d.Source.Line(pos.Line + 1)
+
+	column, units := len(line), 0
+	for i, r := range line {
+		if units >= pos.Character {
+			column = i
+			break
+		}
+		units += utf16.RuneLen(r)
+	}
+
+	return d.Source.Offset(pos.Line+1, column+1)
+}
+
+func (d *Document) Range(span token.Span) Range {
+	return Range{Start: d.Position(span.Start), End: d.Position(span.End)}
+}
+
+// IdentifierAt, returns the identifier under or just before the cursor at offset.
+func (d *Document) IdentifierAt(offset int) (*ast.IdentifierNode, bool) {
+	var found *ast.IdentifierNode
+	ast.Inspect(d.Root, func(n ast.Node) bool {
+		if id, ok := n.(*ast.IdentifierNode); ok && id.Span.Start <= offset && offset <= id.Span.End {
+			found = id
+		}
+		return found == nil
+	})
+
+	return found, found != nil
+}
+
+// Symbol, returns the binding id refers to, or binds.
+func (d *Document) Symbol(id *ast.IdentifierNode) (*resolver.Symbol, bool) {
+	if d.resolver == nil {
+		return nil, false
+	}
+
+	return d.resolver.Symbol(id)
+}
+
+// Definition, returns the identifier that binds the name id refers to,
+// false for builtins and names that are not bound.
+func (d *Document) Definition(id *ast.IdentifierNode) (*ast.IdentifierNode, bool) {
+	s, ok := d.Symbol(id)
+	if !ok || s.Scope == resolver.BuiltinScope {
+		return nil, false
+	}
+
+	for _, b := range d.bindings {
+		if b.id.Span == s.Span {
+			return b.id, true
+		}
+	}
+
+	return nil, false
+}
+
+// References, returns the identifiers referring to the binding made by def, including def itself.
+func (d *Document) References(def *ast.IdentifierNode) []*ast.IdentifierNode {
+	var refs []*ast.IdentifierNode
+	ast.Inspect(d.Root, func(n ast.Node) bool {
+		if id, ok := n.(*ast.IdentifierNode); ok {
+			if s, ok := d.Symbol(id); ok && s.Scope != resolver.BuiltinScope && s.Span == def.Span {
+				refs = append(refs, id)
+			}
+		}
+		return true
+	})
+
+	return refs
+}
+
+// TypeOf, returns the type inferred for the name bound by id, or an expression.
+func (d *Document) TypeOf(node ast.Node) (string, bool) {
+	if d.checker == nil {
+		return "", false
+	}
+
+	t, ok := d.checker.TypeOf(node)
+	if !ok {
+		return "", false
+	}
+
+	return types.String(t), true
+}
+
+// Describe, returns how hover shows the binding id refers to, such as let add: fn(int, int) -> int.
+func (d *Document) Describe(id *ast.IdentifierNode) (string, bool) {
+	if s, ok := d.Symbol(id); ok && s.Scope == resolver.BuiltinScope {
+		return "(builtin) " + s.Name, true
+	}
+
+	def, ok := d.Definition(id)
+	if !ok {
+		return "", false
+	}
+
+	description := def.Value
+	if t, ok := d.TypeOf(def); ok {
+		description += ": " + t
+	}
+
+	b := d.bound[def]
+	if b.kind == "let" {
+		return "let " + description, true
+	}
+
+	return "(" + b.kind + ") " + description, true
+}
+
+// Symbols, returns the names bound by lets, with the lets inside the value of each as its children.
+func (d *Document) Symbols() []DocumentSymbol {
+	return d.symbols(d.Root)
+}
+
+func (d *Document) symbols(node ast.Node) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	ast.Inspect(node, func(n ast.Node) bool {
+		let, ok := n.(*ast.LetNode)
+		if !ok || n == node || let.Identifier == nil {
+			return true
+		}
+
+		s := DocumentSymbol{
+			Name:           let.Identifier.Value,
+			Kind:           SymbolKindVariable,
+			Range:          d.Range(let.Span),
+			SelectionRange: d.Range(let.Identifier.Span),
+			Children:       d.symbols(let),
+		}
+		if fn, ok := let.Value.(*ast.FunctionNode); ok && fn != nil {
+			s.Kind = SymbolKindFunction
+		}
+		if t, ok := d.TypeOf(let.Identifier); ok {
+			s.Detail = t
+		}
+
+		symbols = append(symbols, s)
+		return false
+	})
+
+	return symbols
+}
+
+// Completions, returns the keywords and the names in scope at offset, names bound
+// in inner scopes hiding those bound further out.
+func (d *Document) Completions(offset int) []CompletionItem {
+	visible := map[string]*binding{}
+	for _, b := range d.bindings {
+		// The name being typed is not a completion of itself.
+		if b.id.Span.Start <= offset && offset <= b.id.Span.End {
+			continue
+		}
+
+		scope := b.scope.Location()
+		if b.scope != d.Root && (offset <= scope.Start || offset >= scope.End) {
+			continue
+		}
+
+		if current, ok := visible[b.id.Value]; !ok || d.scopeSize(b.scope) <= d.scopeSize(current.scope) {
+			visible[b.id.Value] = b
+		}
+	}
+
+	var items []CompletionItem
+	for name, b := range visible {
+		item := CompletionItem{Label: name, Kind: CompletionKindVariable}
+		switch b.value.(type) {
+		case *ast.FunctionNode, *ast.MacroNode:
+			item.Kind = CompletionKindFunction
+		}
+		if t, ok := d.TypeOf(b.id); ok {
+			item.Detail = t
+		}
+
+		items = append(items, item)
+	}
+	for _, name := range resolver.Builtins {
+		if _, ok := visible[name]; !ok {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionKindFunction, Detail: "builtin"})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+
+	for _, keyword := range Keywords {
+		items = append(items, CompletionItem{Label: keyword, Kind: CompletionKindKeyword})
+	}
+
+	return items
+}
+
+// scopeSize, the length of the code covered by a scope, the top level covering all of it.
+func (d *Document) scopeSize(n ast.Node) int {
+	if n == ast.Node(d.Root) {
+		return len(d.Source.Code) + 1
+	}
+
+	span := n.Location()
+	return span.End - span.Start
+}
+
+// Format, returns the code as the ast writer prints it. Code with syntax errors or
+// comments is not formatted, since the tree does not hold all of it.
+func (d *Document) Format() (string, error) {
+	if len(d.Syntax) > 0 {
+		return "", fmt.Errorf("cannot format code with syntax errors")
+	}
+	if len(d.Comments) > 0 {
+		return "", fmt.Errorf("cannot format code with comments, formatting would remove them")
+	}
+
+	var b bytes.Buffer
+	ast.NewWriter(&b).Write(d.Root)
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,53 @@
+package lsp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Document_Position(t *testing.T) {
+	// The comment holds a character outside the basic plane, two UTF-16 code units.
+	d := NewDocument(uri, 1, "// 🐒 ok\nlet a = 1;")
+
+	testcases := []struct {
+		name     string
+		offset   int
+		expected Position
+	}{
+		{name: "start", offset: 0, expected: Position{Line: 0, Character: 0}},
+		{name: "before wide", offset: 3, expected: Position{Line: 0, Character: 3}},
+		{name: "after wide", offset: 7, expected: Position{Line: 0, Character: 5}},
+		{name: "next line", offset: 15, expected: Position{Line: 1, Character: 4}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, d.Position(tc.offset))
+			assert.Equal(t, tc.offset, d.Offset(tc.expected))
+		})
+	}
+
+	assert.Equal(t, 10, d.Offset(Position{Line: 0, Character: 100}))
+	assert.Equal(t, 21, d.Offset(Position{Line: 5, Character: 100}))
+}
+
+func Test_Conn(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, NewConn(nil, &b).Write(map[string]int{"id": 1}))
+	assert.Equal(t, "Content-Length: 8\r\n\r\n{\"id\":1}", b.String())
+
+	conn := NewConn(bytes.NewBufferString("Content-Type: application/vscode-jsonrpc; charset=utf-8\r\n"+b.String()), nil)
+	body, err := conn.Read()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, string(body))
+
+	_, err = conn.Read()
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = NewConn(bytes.NewBufferString("\r\n{}"), nil).Read()
+	assert.EqualError(t, err, "missing Content-Length header")
+}
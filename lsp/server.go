@@ -0,0 +1,372 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/token"
+)
+
+// ErrNoShutdown is returned by Run when the client exits, or the input ends, without a shutdown request.
+var ErrNoShutdown = errors.New("exit without shutdown")
+
+// Server, a language server for monkey answering one client over a Conn.
+//
+// The client sends the changes made to each document. Each version is parsed,
+// resolved and type checked, and the problems found published as diagnostics.
+type Server struct {
+	conn      *Conn
+	documents map[string]*Document
+
+	initialized bool
+	shutdown    bool
+}
+
+func New(r io.Reader, w io.Writer) *Server {
+	return &Server{conn: NewConn(r, w), documents: map[string]*Document{}}
+}
+
+// handler, answers a request or handles a notification with its params.
+type handler func(s *Server, params json.RawMessage) (any, error)
+
+// handle, returns a handler decoding the params for f.
+func handle[P any](f func(s *Server, params P) (any, error)) handler {
+	return func(s *Server, raw json.RawMessage) (any, error) {
+		var params P
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+			}
+		}
+
+		return f(s, params)
+	}
+}
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":  handle((*Server).Initialize),
+		"initialized": handle(func(*Server, struct{}) (any, error) { return nil, nil }),
+		"shutdown":    handle((*Server).Shutdown),
+
+		"textDocument/didOpen":   handle((*Server).DidOpen),
+		"textDocument/didChange": handle((*Server).DidChange),
+		"textDocument/didClose":  handle((*Server).DidClose),
+
+		"textDocument/hover":          handle((*Server).Hover),
+		"textDocument/definition":     handle((*Server).Definition),
+		"textDocument/references":     handle((*Server).References),
+		"textDocument/documentSymbol": handle((*Server).DocumentSymbol),
+		"textDocument/completion":     handle((*Server).Completion),
+		"textDocument/formatting":     handle((*Server).Formatting),
+	}
+}
+
+// Run, handles messages until the client exits. It returns nil if the client asked
+// the server to shut down first, as the protocol requires, ErrNoShutdown if not.
+func (s *Server) Run() error {
+	for {
+		body, err := s.conn.Read()
+		if err == io.EOF {
+			if s.shutdown {
+				return nil
+			}
+			return ErrNoShutdown
+		}
+		if err != nil {
+			return err
+		}
+
+		var m message
+		if err := json.Unmarshal(body, &m); err != nil {
+			if err := s.respond(json.RawMessage("null"), nil, &ResponseError{Code: CodeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if m.Method == "exit" {
+			if s.shutdown {
+				return nil
+			}
+			return ErrNoShutdown
+		}
+
+		if err := s.dispatch(&m); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch, handles a message, answering it if it is a request.
+func (s *Server) dispatch(m *message) error {
+	request := len(m.ID) > 0
+
+	h, ok := handlers[m.Method]
+	switch {
+	case !ok:
+		if !request {
+			// Notifications the server does not know, such as $/cancelRequest, can be ignored.
+			return nil
+		}
+		return s.respond(m.ID, nil, &ResponseError{Code: CodeMethodNotFound, Message: fmt.Sprintf("unknown method %q", m.Method)})
+	case !s.initialized && m.Method != "initialize":
+		if !request {
+			return nil
+		}
+		return s.respond(m.ID, nil, &ResponseError{Code: CodeServerNotInitialized, Message: "server not initialized"})
+	case s.shutdown && request:
+		return s.respond(m.ID, nil, &ResponseError{Code: CodeInvalidRequest, Message: "server is shutting down"})
+	}
+
+	result, err := h(s, m.Params)
+	if !request {
+		return nil
+	}
+
+	if err != nil {
+		var re *ResponseError
+		if !errors.As(err, &re) {
+			re = &ResponseError{Code: CodeRequestFailed, Message: err.Error()}
+		}
+		return s.respond(m.ID, nil, re)
+	}
+
+	return s.respond(m.ID, result, nil)
+}
+
+func (s *Server) respond(id json.RawMessage, result any, re *ResponseError) error {
+	m := message{JSONRPC: "2.0", ID: id, Error: re}
+
+	if re == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		m.Result = data
+	}
+
+	return s.conn.Write(m)
+}
+
+// Notify, sends a notification to the client.
+func (s *Server) Notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return s.conn.Write(message{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+func (s *Server) Initialize(struct{}) (any, error) {
+	s.initialized = true
+
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           SyncIncremental,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			ReferencesProvider:         true,
+			DocumentSymbolProvider:     true,
+			DocumentFormattingProvider: true,
+			CompletionProvider:         &CompletionOptions{},
+		},
+		ServerInfo: ServerInfo{Name: "monkey"},
+	}, nil
+}
+
+func (s *Server) Shutdown(struct{}) (any, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) DidOpen(params DidOpenTextDocumentParams) (any, error) {
+	item := params.TextDocument
+	return nil, s.open(NewDocument(item.URI, item.Version, item.Text))
+}
+
+func (s *Server) DidChange(params DidChangeTextDocumentParams) (any, error) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Change(params.TextDocument.Version, params.ContentChanges)
+	return nil, s.open(d)
+}
+
+func (s *Server) DidClose(params DidCloseTextDocumentParams) (any, error) {
+	delete(s.documents, params.TextDocument.URI)
+
+	// Clear the problems shown for the document now nothing is checking it.
+	return nil, s.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// open, stores the new version of a document and publishes its problems.
+func (s *Server) open(d *Document) error {
+	s.documents[d.URI] = d
+
+	diags := []Diagnostic{}
+	for _, diag := range d.Diagnostics() {
+		diags = append(diags, s.diagnostic(d, diag))
+	}
+
+	return s.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         d.URI,
+		Version:     d.Version,
+		Diagnostics: diags,
+	})
+}
+
+// diagnostic, converts a diagnostic to the protocol's form, the hint and notes follow the message.
+func (s *Server) diagnostic(d *Document, diag diagnostics.Diagnostic) Diagnostic {
+	span, _ := diag.Primary()
+
+	result := Diagnostic{
+		Range:    d.Range(span),
+		Severity: SeverityError,
+		Source:   "monkey",
+		Message:  diag.Message,
+	}
+	if diag.Severity == diagnostics.Warning {
+		result.Severity = SeverityWarning
+	}
+
+	if diag.Hint != "" {
+		result.Message += "\nhint: " + diag.Hint
+	}
+	for _, note := range diag.Notes {
+		result.Message += "\nnote: " + note
+	}
+
+	for _, label := range diag.Labels {
+		if !label.Primary && label.Message != "" {
+			result.RelatedInformation = append(result.RelatedInformation, DiagnosticRelatedInformation{
+				Location: Location{URI: d.URI, Range: d.Range(label.Span)},
+				Message:  label.Message,
+			})
+		}
+	}
+
+	return result
+}
+
+func (s *Server) document(uri string) (*Document, error) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &ResponseError{Code: CodeInvalidParams, Message: fmt.Sprintf("document %q is not open", uri)}
+	}
+
+	return d, nil
+}
+
+// identifier, returns the document and the identifier at the position in it, nil if there is none.
+func (s *Server) identifier(params TextDocumentPositionParams) (*Document, *ast.IdentifierNode, error) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, _ := d.IdentifierAt(d.Offset(params.Position))
+	return d, id, nil
+}
+
+func (s *Server) Hover(params TextDocumentPositionParams) (any, error) {
+	d, id, err := s.identifier(params)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	description, ok := d.Describe(id)
+	if !ok {
+		return nil, nil
+	}
+
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```monkey\n" + description + "\n```"},
+		Range:    d.Range(id.Span),
+	}, nil
+}
+
+func (s *Server) Definition(params TextDocumentPositionParams) (any, error) {
+	d, id, err := s.identifier(params)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	def, ok := d.Definition(id)
+	if !ok {
+		return nil, nil
+	}
+
+	return Location{URI: d.URI, Range: d.Range(def.Span)}, nil
+}
+
+func (s *Server) References(params ReferenceParams) (any, error) {
+	d, id, err := s.identifier(params.TextDocumentPositionParams)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	def, ok := d.Definition(id)
+	if !ok {
+		return nil, nil
+	}
+
+	locations := []Location{}
+	for _, ref := range d.References(def) {
+		if ref == def && !params.Context.IncludeDeclaration {
+			continue
+		}
+		locations = append(locations, Location{URI: d.URI, Range: d.Range(ref.Span)})
+	}
+
+	return locations, nil
+}
+
+func (s *Server) DocumentSymbol(params DocumentSymbolParams) (any, error) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Symbols(), nil
+}
+
+func (s *Server) Completion(params TextDocumentPositionParams) (any, error) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Completions(d.Offset(params.Position)), nil
+}
+
+func (s *Server) Formatting(params DocumentFormattingParams) (any, error) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := d.Format()
+	if err != nil {
+		return nil, err
+	}
+
+	edits := []TextEdit{}
+	if text != d.Source.Code {
+		edits = append(edits, TextEdit{Range: d.Range(token.Span{End: len(d.Source.Code)}), NewText: text})
+	}
+
+	return edits, nil
+}
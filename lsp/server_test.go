@@ -0,0 +1,388 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uri = "file:///test.mk"
+
+// client, talks to a server running in the same process over pipes.
+type client struct {
+	t    *testing.T
+	conn *Conn
+	next int
+	done chan error
+}
+
+func connect(t *testing.T) *client {
+	t.Helper()
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, conn: NewConn(clientIn, clientOut), done: make(chan error, 1)}
+	go func() {
+		c.done <- New(serverIn, serverOut).Run()
+		serverOut.Close()
+	}()
+
+	return c
+}
+
+// initialize, connects and initializes a server, shutting it down at the end of the test.
+func initialize(t *testing.T) *client {
+	t.Helper()
+
+	c := connect(t)
+	require.Nil(t, c.request("initialize", map[string]any{"capabilities": map[string]any{}}, nil))
+	c.notify("initialized", struct{}{})
+
+	t.Cleanup(func() {
+		require.Nil(t, c.request("shutdown", nil, nil))
+		c.notify("exit", nil)
+		assert.NoError(t, <-c.done)
+	})
+
+	return c
+}
+
+// request, sends a request and decodes its result into result, returning the error it failed with.
+func (c *client) request(method string, params, result any) *ResponseError {
+	c.t.Helper()
+
+	c.next++
+	id := json.RawMessage(strconv.Itoa(c.next))
+	require.NoError(c.t, c.conn.Write(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}))
+
+	for {
+		m := c.read()
+		if m.Method != "" {
+			continue
+		}
+
+		require.Equal(c.t, string(id), string(m.ID))
+		if m.Error != nil {
+			return m.Error
+		}
+		if result != nil {
+			require.NoError(c.t, json.Unmarshal(m.Result, result))
+		}
+		return nil
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.Write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params}))
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+
+	body, err := c.conn.Read()
+	require.NoError(c.t, err)
+
+	var m message
+	require.NoError(c.t, json.Unmarshal(body, &m))
+	return m
+}
+
+// diagnostics, waits for the next diagnostics published.
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+
+	m := c.read()
+	require.Equal(c.t, "textDocument/publishDiagnostics", m.Method)
+
+	var params PublishDiagnosticsParams
+	require.NoError(c.t, json.Unmarshal(m.Params, &params))
+	return params
+}
+
+// open, opens a document returning the diagnostics published for it.
+func (c *client) open(text string) []Diagnostic {
+	c.t.Helper()
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "monkey", Version: 1, Text: text},
+	})
+	return c.diagnostics().Diagnostics
+}
+
+func at(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: line, Character: character}}
+}
+
+func span(line, start, end int) Range {
+	return Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: end}}
+}
+
+func Test_Server_Lifecycle(t *testing.T) {
+	c := connect(t)
+
+	err := c.request("textDocument/hover", at(0, 0), nil)
+	require.NotNil(t, err)
+	assert.Equal(t, CodeServerNotInitialized, err.Code)
+
+	var result InitializeResult
+	require.Nil(t, c.request("initialize", map[string]any{}, &result))
+	assert.Equal(t, SyncIncremental, result.Capabilities.TextDocumentSync)
+	assert.True(t, result.Capabilities.HoverProvider)
+	assert.Equal(t, "monkey", result.ServerInfo.Name)
+
+	err = c.request("workspace/symbol", map[string]any{}, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, CodeMethodNotFound, err.Code)
+
+	err = c.request("textDocument/hover", at(0, 0), nil)
+	require.NotNil(t, err)
+	assert.Equal(t, CodeInvalidParams, err.Code)
+
+	require.Nil(t, c.request("shutdown", nil, nil))
+	c.notify("exit", nil)
+	assert.NoError(t, <-c.done)
+}
+
+func Test_Server_ExitWithoutShutdown(t *testing.T) {
+	c := connect(t)
+	c.notify("exit", nil)
+	assert.ErrorIs(t, <-c.done, ErrNoShutdown)
+}
+
+func Test_Server_Diagnostics(t *testing.T) {
+	c := initialize(t)
+
+	diags := c.open("let a = 1;\na + true;\nb")
+	require.Len(t, diags, 2)
+	assert.Equal(t, "mismatched types: expected int, found bool", diags[0].Message)
+	assert.Equal(t, span(1, 4, 8), diags[0].Range)
+	assert.Equal(t, SeverityError, diags[0].Severity)
+	assert.Equal(t, "undefined name 'b'", diags[1].Message)
+	assert.Equal(t, span(2, 0, 1), diags[1].Range)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let a = 1;\nlet f = fn(x) { a };\nf(1)"}},
+	})
+	published := c.diagnostics()
+	assert.Equal(t, 2, published.Version)
+	require.Len(t, published.Diagnostics, 1)
+	assert.Equal(t, "unused parameter 'x'\nhint: start the name with an underscore if this is intended", published.Diagnostics[0].Message)
+	assert.Equal(t, SeverityWarning, published.Diagnostics[0].Severity)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let = 1;"}},
+	})
+	published = c.diagnostics()
+	require.Len(t, published.Diagnostics, 1)
+	assert.Equal(t, "expected identifier after let, found '='", published.Diagnostics[0].Message)
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	assert.Empty(t, c.diagnostics().Diagnostics)
+}
+
+func Test_Server_IncrementalChange(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open("let a = 1;\nlet b = a + 2;\nb"))
+
+	// Two changes in one notification, the second made to the result of the first.
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Range: &Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 9}}, Text: "true"},
+			{Range: &Range{Start: Position{Line: 2, Character: 0}, End: Position{Line: 2, Character: 0}}, Text: "a + "},
+		},
+	})
+	diags := c.diagnostics().Diagnostics
+	require.Len(t, diags, 2)
+	assert.Equal(t, span(1, 8, 9), diags[0].Range)
+	assert.Equal(t, span(2, 0, 1), diags[1].Range)
+
+	var hover Hover
+	require.Nil(t, c.request("textDocument/hover", at(2, 0), &hover))
+	assert.Equal(t, "```monkey\nlet a: bool\n```", hover.Contents.Value)
+}
+
+const program = `let add = fn(a, b) { a + b };
+let id = fn(x) { x };
+let total = add(1, 2);
+add(total, id(3))`
+
+func Test_Server_Hover(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open(program))
+
+	testcases := []struct {
+		name     string
+		given    TextDocumentPositionParams
+		expected string
+	}{
+		{name: "let", given: at(0, 5), expected: "let add: fn(int, int) -> int"},
+		{name: "use", given: at(3, 1), expected: "let add: fn(int, int) -> int"},
+		{name: "end of name", given: at(3, 9), expected: "let total: int"},
+		{name: "parameter", given: at(0, 21), expected: "(parameter) a: int"},
+		{name: "polymorphic", given: at(1, 4), expected: "let id: fn(a) -> a"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var hover Hover
+			require.Nil(t, c.request("textDocument/hover", tc.given, &hover))
+			assert.Equal(t, "markdown", hover.Contents.Kind)
+			assert.Equal(t, "```monkey\n"+tc.expected+"\n```", hover.Contents.Value)
+		})
+	}
+
+	var hover *Hover
+	require.Nil(t, c.request("textDocument/hover", at(0, 9), &hover))
+	assert.Nil(t, hover)
+}
+
+func Test_Server_HoverLoopVariable(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open("for (i in 0..3) { i + 1; }"))
+
+	var hover Hover
+	require.Nil(t, c.request("textDocument/hover", at(0, 18), &hover))
+	assert.Equal(t, "```monkey\n(loop variable) i: int\n```", hover.Contents.Value)
+}
+
+func Test_Server_Definition(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open(program))
+
+	var location *Location
+	require.Nil(t, c.request("textDocument/definition", at(3, 6), &location))
+	require.NotNil(t, location)
+	assert.Equal(t, Location{URI: uri, Range: span(2, 4, 9)}, *location)
+
+	require.Nil(t, c.request("textDocument/definition", at(0, 22), &location))
+	require.NotNil(t, location)
+	assert.Equal(t, span(0, 13, 14), location.Range)
+
+	location = nil
+	require.Nil(t, c.request("textDocument/definition", at(1, 0), &location))
+	assert.Nil(t, location)
+}
+
+func Test_Server_References(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open(program))
+
+	params := ReferenceParams{TextDocumentPositionParams: at(3, 0)}
+	params.Context.IncludeDeclaration = true
+
+	var locations []Location
+	require.Nil(t, c.request("textDocument/references", params, &locations))
+	assert.Equal(t, []Location{{URI: uri, Range: span(0, 4, 7)}, {URI: uri, Range: span(2, 12, 15)}, {URI: uri, Range: span(3, 0, 3)}}, locations)
+
+	params.Context.IncludeDeclaration = false
+	require.Nil(t, c.request("textDocument/references", params, &locations))
+	assert.Equal(t, []Location{{URI: uri, Range: span(2, 12, 15)}, {URI: uri, Range: span(3, 0, 3)}}, locations)
+}
+
+func Test_Server_DocumentSymbol(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open("let f = fn(n) {\n\tlet m = n * 2;\n\tm\n};\nlet a = f(1);\na"))
+
+	var symbols []DocumentSymbol
+	require.Nil(t, c.request("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols))
+	require.Len(t, symbols, 2)
+
+	assert.Equal(t, "f", symbols[0].Name)
+	assert.Equal(t, SymbolKindFunction, symbols[0].Kind)
+	assert.Equal(t, "fn(int) -> int", symbols[0].Detail)
+	assert.Equal(t, span(0, 4, 5), symbols[0].SelectionRange)
+	require.Len(t, symbols[0].Children, 1)
+	assert.Equal(t, "m", symbols[0].Children[0].Name)
+	assert.Equal(t, SymbolKindVariable, symbols[0].Children[0].Kind)
+
+	assert.Equal(t, "a", symbols[1].Name)
+	assert.Equal(t, "int", symbols[1].Detail)
+	assert.Empty(t, symbols[1].Children)
+}
+
+func Test_Server_Completion(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open("let a = 1;\nlet f = fn(a, b) {\n\ta + b\n};\nf(a, 2)"))
+
+	labels := func(items []CompletionItem) map[string]CompletionItem {
+		result := map[string]CompletionItem{}
+		for _, item := range items {
+			result[item.Label] = item
+		}
+		return result
+	}
+
+	var items []CompletionItem
+	require.Nil(t, c.request("textDocument/completion", at(2, 1), &items))
+	inside := labels(items)
+	assert.Contains(t, inside, "b")
+	assert.Contains(t, inside, "f")
+	assert.Contains(t, inside, "let")
+	assert.Contains(t, inside, "quote")
+	assert.Equal(t, CompletionKindKeyword, inside["fn"].Kind)
+	assert.Equal(t, CompletionKindFunction, inside["f"].Kind)
+
+	require.Nil(t, c.request("textDocument/completion", at(4, 0), &items))
+	outside := labels(items)
+	assert.NotContains(t, outside, "b")
+	assert.Equal(t, CompletionKindVariable, outside["a"].Kind)
+	assert.Equal(t, "int", outside["a"].Detail)
+}
+
+func Test_Server_Formatting(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open("let add=fn(a,b){a+b};\nadd(1,2)"))
+
+	params := DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+
+	var edits []TextEdit
+	require.Nil(t, c.request("textDocument/formatting", params, &edits))
+	require.Len(t, edits, 1)
+	assert.Equal(t, Range{End: Position{Line: 1, Character: 8}}, edits[0].Range)
+	assert.Equal(t, "let add = fn(a, b) {\n\t(a + b)\n};\nadd(1, 2)\n", edits[0].NewText)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: edits[0].NewText}},
+	})
+	c.diagnostics()
+
+	require.Nil(t, c.request("textDocument/formatting", params, &edits))
+	assert.Empty(t, edits)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "// the answer\n42"}},
+	})
+	c.diagnostics()
+
+	err := c.request("textDocument/formatting", params, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, CodeRequestFailed, err.Code)
+}
+
+func Test_Server_FormattingReparses(t *testing.T) {
+	c := initialize(t)
+	require.Empty(t, c.open("let n=0;let i=0;\nwhile(i<3){if(i==1){n+=1}else if(i==2){n+=2}else{n+=3};i+=1}\nfor(j in 0..3){if(!false){n+=j}}\nn"))
+
+	params := DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+
+	var edits []TextEdit
+	require.Nil(t, c.request("textDocument/formatting", params, &edits))
+	require.Len(t, edits, 1)
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: edits[0].NewText}},
+	})
+	assert.Empty(t, c.diagnostics().Diagnostics, edits[0].NewText)
+}
@@ -5,26 +5,58 @@ import (
 	"os"
 	"os/user"
 
+	"github.com/maybe-joe/monkey/highlight"
 	"github.com/maybe-joe/monkey/repl"
 )
 
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		fmt.Printf("Error: %+v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
+func run(args []string) error {
+	if len(args) == 0 {
+		return interactive()
+	}
+
+	switch command, args := args[0], args[1:]; command {
+	case "repl":
+		return interactive()
+	case "serve":
+		return serve(args)
+	case "run":
+		return runScript(args)
+	case "ast":
+		return dumpAst(args)
+	case "check":
+		return checkSource(args)
+	case "lint":
+		return lintSource(args)
+	case "highlight":
+		return highlightSource(args)
+	case "lsp":
+		return languageServer(args)
+	case "refactor":
+		return refactorSource(args)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func interactive() error {
 	u, err := user.Current()
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Hello %s! This is the Monkey programming language!\n", u.Username)
-	fmt.Printf("Feel free to type in commands\n")
+	r := repl.New(os.Stdin, os.Stdout)
+	r.Err = os.Stderr
+	r.Formatter = highlight.For(os.Stdout)
+	r.Banner = fmt.Sprintf("Hello %s! This is the Monkey programming language!\nFeel free to type in commands\n", u.Username)
 
-	if err := repl.Run(os.Stdin, os.Stdout); err != nil {
+	if err := r.Run(); err != nil {
 		return err
 	}
 
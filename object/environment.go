@@ -0,0 +1,48 @@
+package object
+
+// Environment, the bindings visible to the code being evaluated.
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+}
+
+// NewEnvironment creates an empty top level environment.
+func NewEnvironment() *Environment {
+	return &Environment{store: map[string]Object{}}
+}
+
+// NewEnclosedEnvironment creates an empty environment that falls back to outer for lookups.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// Get, looks up name in this environment and then in each enclosing one.
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		return e.outer.Get(name)
+	}
+
+	return obj, ok
+}
+
+// Assign, rebinds name to val in the innermost environment that binds it,
+// returning false if none of them do.
+func (e *Environment) Assign(name string, val Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = val
+			return true
+		}
+	}
+
+	return false
+}
+
+// Set, binds name to val in this environment.
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
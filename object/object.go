@@ -0,0 +1,176 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/token"
+)
+
+type ObjectType string
+
+const (
+	INTEGER_OBJ      ObjectType = "INTEGER"
+	BOOLEAN_OBJ      ObjectType = "BOOLEAN"
+	NULL_OBJ         ObjectType = "NULL"
+	RETURN_VALUE_OBJ ObjectType = "RETURN_VALUE"
+	FUNCTION_OBJ     ObjectType = "FUNCTION"
+	ERROR_OBJ        ObjectType = "ERROR"
+	QUOTE_OBJ        ObjectType = "QUOTE"
+	MACRO_OBJ        ObjectType = "MACRO"
+	BREAK_OBJ        ObjectType = "BREAK"
+	CONTINUE_OBJ     ObjectType = "CONTINUE"
+	RANGE_OBJ        ObjectType = "RANGE"
+)
+
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+
+type Null struct{}
+
+func (n *Null) Type() ObjectType { return NULL_OBJ }
+func (n *Null) Inspect() string  { return "null" }
+
+// Range, the integers from Start up to but not including End.
+type Range struct {
+	Start int64
+	End   int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string  { return fmt.Sprintf("%d..%d", r.Start, r.End) }
+
+// ReturnValue, wraps the value of a return statement while it unwinds to the enclosing function.
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+// Break, signals a break while it unwinds to the enclosing loop.
+type Break struct {
+	Node *ast.BreakNode
+}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+// Continue, signals a continue while it unwinds to the enclosing loop.
+type Continue struct {
+	Node *ast.ContinueNode
+}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
+type Function struct {
+	// Name, the name the function was first bound to, empty for anonymous functions.
+	Name       string
+	Parameters []*ast.IdentifierNode
+	// ParameterTypes and ReturnType, the annotations of the function, checked when it is called.
+	ParameterTypes []*ast.TypeNode
+	ReturnType     *ast.TypeNode
+	Body           *ast.BlockNode
+	Env            *Environment
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	var buf bytes.Buffer
+	ast.NewWriter(&buf).Write(&ast.FunctionNode{
+		Parameters:     f.Parameters,
+		ParameterTypes: f.ParameterTypes,
+		ReturnType:     f.ReturnType,
+		Body:           f.Body,
+	})
+	return buf.String()
+}
+
+// Quote, an unevaluated piece of code produced by quote.
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() ObjectType { return QUOTE_OBJ }
+func (q *Quote) Inspect() string {
+	var buf bytes.Buffer
+	buf.WriteString("QUOTE(")
+	ast.NewWriter(&buf).Write(q.Node)
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// Macro, a macro definition. Macros are called with their arguments quoted
+// and must return a Quote, which replaces the call before evaluation.
+type Macro struct {
+	Parameters []*ast.IdentifierNode
+	Body       *ast.BlockNode
+	Env        *Environment
+}
+
+func (m *Macro) Type() ObjectType { return MACRO_OBJ }
+func (m *Macro) Inspect() string {
+	var buf bytes.Buffer
+	ast.NewWriter(&buf).Write(&ast.MacroNode{Parameters: m.Parameters, Body: m.Body})
+	return buf.String()
+}
+
+// Frame, a function call that was in progress when an error occurred.
+type Frame struct {
+	// Function, the name of the function called.
+	Function string
+	// Span, the location of the call.
+	Span token.Span
+}
+
+// MaxFrames, the number of frames included when an error is turned into a diagnostic.
+const MaxFrames = 10
+
+// Error, a runtime error. Evaluation stops at the first error.
+type Error struct {
+	Message string
+	// Span, the location of the node that failed.
+	Span token.Span
+	// Stack, the calls in progress when the error occurred, innermost first.
+	Stack []Frame
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+func (e *Error) Error() string    { return e.Message }
+
+// Diagnostic, converts the error into a diagnostic with the stack as notes.
+func (e *Error) Diagnostic(src *diagnostics.Source) diagnostics.Diagnostic {
+	d := diagnostics.Errorf(e.Span, "%s", e.Message)
+
+	for i, frame := range e.Stack {
+		if i == MaxFrames {
+			d = d.WithNote(fmt.Sprintf("... %d more frames", len(e.Stack)-MaxFrames))
+			break
+		}
+
+		pos := src.Position(frame.Span.Start)
+		d = d.WithNote(fmt.Sprintf("in %s, called at %s:%d:%d", frame.Function, src.Name, pos.Line, pos.Column))
+	}
+
+	return d
+}
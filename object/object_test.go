@@ -0,0 +1,52 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Error_Diagnostic(t *testing.T) {
+	src := diagnostics.NewSource("main.mk", "let f = fn() { 1 + true };\nf();")
+
+	err := &Error{
+		Message: "type mismatch: INTEGER + BOOLEAN",
+		Span:    token.Span{Start: 15, End: 23},
+		Stack:   []Frame{{Function: "f", Span: token.Span{Start: 27, End: 30}}},
+	}
+
+	d := err.Diagnostic(src)
+	assert.Equal(t, "type mismatch: INTEGER + BOOLEAN", d.Message)
+	assert.Equal(t, []diagnostics.Label{{Span: err.Span, Primary: true}}, d.Labels)
+	assert.Equal(t, []string{"in f, called at main.mk:2:1"}, d.Notes)
+}
+
+func Test_Error_Diagnostic_Truncated(t *testing.T) {
+	src := diagnostics.NewSource("main.mk", "f()")
+
+	err := &Error{Message: "boom"}
+	for range MaxFrames + 5 {
+		err.Stack = append(err.Stack, Frame{Function: "f", Span: token.Span{Start: 0, End: 3}})
+	}
+
+	notes := err.Diagnostic(src).Notes
+	assert.Len(t, notes, MaxFrames+1)
+	assert.Equal(t, "... 5 more frames", notes[MaxFrames])
+}
+
+func Test_Environment(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("y", &Integer{Value: 2})
+
+	x, ok := inner.Get("x")
+	assert.True(t, ok)
+	assert.Equal(t, &Integer{Value: 1}, x)
+
+	_, ok = outer.Get("y")
+	assert.False(t, ok)
+}
@@ -0,0 +1,185 @@
+package optimizer
+
+import (
+	"github.com/maybe-joe/monkey/ast"
+)
+
+// InlineLets, replaces identifiers bound to an integer or boolean literal with the literal.
+//
+// A let is only inlined when it is the sole binding of its name in the environment it
+// runs in, so the program, its functions and their bodies, and only into the statements
+// following it. Blocks share the environment of the function around them, so a let
+// inside an if counts as a second binding, as does an assignment anywhere in the scope.
+// Functions and loops that bind the name themselves and quoted code are left alone.
+// The lets are kept, since they may still be used by code before them that runs later,
+// such as a function called after the let.
+func InlineLets(node ast.Node) ast.Node {
+	var scopes [][]ast.Statement
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.RootNode:
+			scopes = append(scopes, n.Statements)
+		case *ast.FunctionNode:
+			if n.Body != nil {
+				scopes = append(scopes, n.Body.Statements)
+			}
+		case *ast.MacroNode:
+			if n.Body != nil {
+				scopes = append(scopes, n.Body.Statements)
+			}
+		}
+
+		return true
+	})
+
+	for _, stmts := range scopes {
+		inlineScope(stmts)
+	}
+
+	return node
+}
+
+func inlineScope(stmts []ast.Statement) {
+	counts := map[string]int{}
+	for _, stmt := range stmts {
+		countBindings(stmt, counts)
+	}
+
+	for i, stmt := range stmts {
+		let, ok := stmt.(*ast.LetNode)
+		if !ok || let.Identifier == nil || counts[let.Identifier.Value] != 1 || !isLiteral(let.Value) {
+			continue
+		}
+
+		for _, later := range stmts[i+1:] {
+			substitute(later, let.Identifier.Value, let.Value)
+		}
+	}
+}
+
+// countBindings, counts the lets under node that bind a name in its environment and
+// the assignments anywhere under it, since a function may assign to a name of the
+// environment around it.
+func countBindings(node ast.Node, counts map[string]int) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionNode, *ast.MacroNode:
+			ast.Inspect(n, func(n ast.Node) bool {
+				if assign, ok := n.(*ast.AssignNode); ok {
+					counts[assign.Name.Value]++
+				}
+				return true
+			})
+			return false
+		case *ast.LetNode:
+			if n.Identifier != nil {
+				counts[n.Identifier.Value]++
+			}
+		case *ast.AssignNode:
+			counts[n.Name.Value]++
+		}
+
+		return true
+	})
+}
+
+// substitute, replaces the uses of name under node with copies of value.
+func substitute(node ast.Node, name string, value ast.Expression) {
+	uses := map[*ast.IdentifierNode]bool{}
+
+	var visit func(ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionNode:
+			return !binds(n.Parameters, n.Body, name)
+		case *ast.MacroNode:
+			return !binds(n.Parameters, n.Body, name)
+		case *ast.ForNode:
+			// The iterable is evaluated before the loop variable is bound.
+			if loopBinds(n, name) {
+				ast.Inspect(n.Iterable, visit)
+				return false
+			}
+		case *ast.CallNode:
+			return !isQuote(n)
+		case *ast.IdentifierNode:
+			if n.Value == name {
+				uses[n] = true
+			}
+		}
+
+		return true
+	}
+	ast.Inspect(node, visit)
+
+	if len(uses) == 0 {
+		return
+	}
+
+	ast.Modify(node, func(n ast.Node) ast.Node {
+		if id, ok := n.(*ast.IdentifierNode); ok && uses[id] {
+			return literalAt(value, id)
+		}
+
+		return n
+	})
+}
+
+// binds, returns true if a function with params and body binds name anywhere inside it.
+func binds(params []*ast.IdentifierNode, body *ast.BlockNode, name string) bool {
+	for _, param := range params {
+		if param.Value == name {
+			return true
+		}
+	}
+
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetNode:
+			if n.Identifier != nil && n.Identifier.Value == name {
+				found = true
+			}
+		case *ast.ForNode:
+			if loopBinds(n, name) {
+				found = true
+			}
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// loopBinds, returns true if name is the loop variable of n.
+func loopBinds(n *ast.ForNode, name string) bool {
+	return n.Value != nil && n.Value.Value == name
+}
+
+func isQuote(call *ast.CallNode) bool {
+	id, ok := call.Function.(*ast.IdentifierNode)
+	return ok && id.Value == "quote"
+}
+
+func isLiteral(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.IntegerNode, *ast.BooleanNode:
+		return true
+	default:
+		return false
+	}
+}
+
+// literalAt, copies the literal value to the location of the identifier it replaces.
+func literalAt(value ast.Expression, at *ast.IdentifierNode) ast.Expression {
+	switch v := value.(type) {
+	case *ast.IntegerNode:
+		return &ast.IntegerNode{Value: v.Value, Span: at.Span}
+	case *ast.BooleanNode:
+		return &ast.BooleanNode{Value: v.Value, Span: at.Span}
+	default:
+		return value
+	}
+}
@@ -0,0 +1,91 @@
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maybe-joe/monkey/ast"
+)
+
+// Passes, selects the optimisations run by Optimize.
+type Passes struct {
+	// FoldConstants, evaluates operators whose operands are literals.
+	FoldConstants bool
+	// EliminateBranches, replaces an if with a literal condition by the branch taken.
+	EliminateBranches bool
+	// RemoveUnreachable, drops the statements following a return.
+	RemoveUnreachable bool
+	// InlineLets, replaces identifiers bound once to a literal with the literal.
+	InlineLets bool
+}
+
+// All, every optimisation enabled.
+var All = Passes{
+	FoldConstants:     true,
+	EliminateBranches: true,
+	RemoveUnreachable: true,
+	InlineLets:        true,
+}
+
+var passNames = map[string]func(*Passes){
+	"fold":        func(p *Passes) { p.FoldConstants = true },
+	"branches":    func(p *Passes) { p.EliminateBranches = true },
+	"unreachable": func(p *Passes) { p.RemoveUnreachable = true },
+	"inline":      func(p *Passes) { p.InlineLets = true },
+	"all":         func(p *Passes) { *p = All },
+}
+
+// ParsePasses, parses a comma separated list of passes such as "fold,inline".
+// The names are fold, branches, unreachable and inline, or all for every pass.
+func ParsePasses(list string) (Passes, error) {
+	var passes Passes
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		enable, ok := passNames[name]
+		if !ok {
+			return Passes{}, fmt.Errorf("unknown optimisation %q", name)
+		}
+
+		enable(&passes)
+	}
+
+	return passes, nil
+}
+
+// maxRounds, limits how many times the passes are repeated looking for more to do.
+const maxRounds = 10
+
+// Optimize, returns an optimised copy of root, leaving root itself unchanged.
+// The passes are repeated while they keep changing the tree, since one pass
+// often creates work for another, as when an inlined let makes an operator constant.
+func Optimize(root *ast.RootNode, passes Passes) *ast.RootNode {
+	root = ast.Clone(root)
+
+	for range maxRounds {
+		before := ast.Hash(root)
+
+		if passes.InlineLets {
+			InlineLets(root)
+		}
+		if passes.FoldConstants {
+			FoldConstants(root)
+		}
+		if passes.EliminateBranches {
+			EliminateBranches(root)
+		}
+		if passes.RemoveUnreachable {
+			RemoveUnreachable(root)
+		}
+
+		if ast.Hash(root) == before {
+			break
+		}
+	}
+
+	return root
+}
@@ -0,0 +1,150 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/evaluator"
+	"github.com/maybe-joe/monkey/object"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, code string) *ast.RootNode {
+	t.Helper()
+
+	p := parser.New(token.NewTokenizer(code))
+	root := p.Parse()
+	require.Empty(t, p.Errors())
+
+	return root
+}
+
+func Test_Passes(t *testing.T) {
+	testcases := []struct {
+		name     string
+		passes   Passes
+		given    string
+		expected string
+	}{
+		{name: "fold arithmetic", passes: Passes{FoldConstants: true}, given: "1 + 2 * 3", expected: "7"},
+		{name: "fold comparison", passes: Passes{FoldConstants: true}, given: "1 < 2 == true", expected: "true"},
+		{name: "fold prefix", passes: Passes{FoldConstants: true}, given: "!-5; !!true", expected: "false; true"},
+		{name: "fold coalesce", passes: Passes{FoldConstants: true}, given: "null ?? 1 + 2; 3 ?? x; x ?? 4", expected: "3; 3; x ?? 4"},
+		{name: "fold keeps division by zero", passes: Passes{FoldConstants: true}, given: "1 / (1 - 1)", expected: "1 / 0"},
+		{name: "fold keeps type mismatch", passes: Passes{FoldConstants: true}, given: "1 + true; true + false", expected: "1 + true; true + false"},
+		{name: "fold keeps identifiers", passes: Passes{FoldConstants: true}, given: "x + 1 * 2", expected: "x + 2"},
+		{name: "branch expression", passes: Passes{EliminateBranches: true}, given: "let a = if (true) { 1 } else { 2 };", expected: "let a = 1;"},
+		{name: "branch else", passes: Passes{EliminateBranches: true}, given: "let a = if (0) { x } else { y };", expected: "let a = x;"},
+		{name: "branch statement", passes: Passes{EliminateBranches: true}, given: "if (false) { a } else { let b = 1; b }", expected: "let b = 1; b"},
+		{name: "branch removed", passes: Passes{EliminateBranches: true}, given: "if (false) { a }; b", expected: "b"},
+		{name: "branch conditional", passes: Passes{EliminateBranches: true}, given: "let a = true ? x : y; let b = null ? x : 0 ? y : z;", expected: "let a = x; let b = y;"},
+		{name: "branch else if", passes: Passes{EliminateBranches: true}, given: "let a = if (x) { 1 } else if (false) { 2 } else { 3 };", expected: "let a = if (x) { 1 } else { 3 };"},
+		{name: "branch value kept", passes: Passes{EliminateBranches: true}, given: "a; if (false) { b }", expected: "a; if (false) { b }"},
+		{name: "branch let kept", passes: Passes{EliminateBranches: true}, given: "if (true) { let b = 1; }", expected: "if (true) { let b = 1; }"},
+		{name: "branch unknown", passes: Passes{EliminateBranches: true}, given: "if (x) { 1 }", expected: "if (x) { 1 }"},
+		{name: "unreachable", passes: Passes{RemoveUnreachable: true}, given: "fn() { return 1; 2; 3 }", expected: "fn() { return 1; }"},
+		{name: "unreachable root", passes: Passes{RemoveUnreachable: true}, given: "1; return 2; 3", expected: "1; return 2;"},
+		{name: "unreachable in loop", passes: Passes{RemoveUnreachable: true}, given: "while (x) { break; 1; } while (y) { continue; 2 }", expected: "while (x) { break; } while (y) { continue; }"},
+		{name: "inline rebound in loop", passes: Passes{InlineLets: true}, given: "let i = 0; while (i < 3) { let i = i + 1; }; i", expected: "let i = 0; while (i < 3) { let i = i + 1; }; i"},
+		{name: "inline shadowed by loop variable", passes: Passes{InlineLets: true}, given: "let a = 3; for (a in 0..a) { a }; fn() { for (a in a..4) { a } }", expected: "let a = 3; for (a in 0..3) { a }; fn() { for (a in a..4) { a } }"},
+		{name: "inline", passes: Passes{InlineLets: true}, given: "let a = 1; a + a", expected: "let a = 1; 1 + 1"},
+		{name: "inline into functions", passes: Passes{InlineLets: true}, given: "let a = 1; fn(b) { a + b }", expected: "let a = 1; fn(b) { 1 + b }"},
+		{name: "inline not before", passes: Passes{InlineLets: true}, given: "let f = fn() { a }; let a = 1; f()", expected: "let f = fn() { a }; let a = 1; f()"},
+		{name: "inline shadowed by parameter", passes: Passes{InlineLets: true}, given: "let a = 1; fn(a) { a }", expected: "let a = 1; fn(a) { a }"},
+		{name: "inline shadowed by let", passes: Passes{InlineLets: true}, given: "let a = 1; fn() { let a = 2; a }", expected: "let a = 1; fn() { let a = 2; 2 }"},
+		{name: "inline shadowed by earlier let", passes: Passes{InlineLets: true}, given: "let x = 1; let f = fn(a) { let x = 2; let y = 3; x }; f(0)", expected: "let x = 1; let f = fn(a) { let x = 2; let y = 3; 2 }; f(0)"},
+		{name: "inline rebound in block", passes: Passes{InlineLets: true}, given: "let a = 1; if (x) { let a = 2; }; a", expected: "let a = 1; if (x) { let a = 2; }; a"},
+		{name: "inline assigned", passes: Passes{InlineLets: true}, given: "let a = 1; a += 1; a", expected: "let a = 1; a += 1; a"},
+		{name: "inline assigned in function", passes: Passes{InlineLets: true}, given: "let a = 1; let f = fn() { a = 2 }; f(); a", expected: "let a = 1; let f = fn() { a = 2 }; f(); a"},
+		{name: "inline assigned in loop", passes: Passes{InlineLets: true}, given: "let i = 0; while (i < 3) { i = i + 1; }", expected: "let i = 0; while (i < 3) { i = i + 1; }"},
+		{name: "inline not quoted", passes: Passes{InlineLets: true}, given: "let a = 1; quote(a)", expected: "let a = 1; quote(a)"},
+		{name: "inline only literals", passes: Passes{InlineLets: true}, given: "let a = b; a", expected: "let a = b; a"},
+		{name: "all", passes: All, given: "let n = 2; let f = fn(x) { if (n > 1) { return x * n; } x }; f(n * 3)", expected: "let n = 2; let f = fn(x) { return x * 2; }; f(6)"},
+		{name: "none", passes: Passes{}, given: "let a = 1; a + 2", expected: "let a = 1; a + 2"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Optimize(parse(t, tc.given), tc.passes)
+			assert.Empty(t, ast.Diff(parse(t, tc.expected), actual, ast.IgnoreSpans()))
+		})
+	}
+}
+
+func Test_Optimize_LeavesInput(t *testing.T) {
+	root := parse(t, "1 + 2")
+	before := ast.Clone(root)
+
+	Optimize(root, All)
+
+	assert.True(t, ast.Equal(before, root))
+}
+
+func Test_Optimize_Semantics(t *testing.T) {
+	programs := []string{
+		"(5 + 10 * 2 + 15 / 3) * 2 + -10",
+		"1 < 2 == true",
+		"!!5; !true == false",
+		"if (1 > 2) { 10 } else { 20 }",
+		"if (false) { 10 }",
+		"if (true) { if (true) { return 10; } return 1; }",
+		"let a = 5; let b = a * 2; b + a;",
+		"let a = 5;",
+		"let add = fn(x, y) { x + y; }; add(5, add(1, 1));",
+		"let adder = fn(x) { fn(y) { x + y } }; adder(2)(3);",
+		"let f = fn(n) { if (n < 1) { 0 } else { n + f(n - 1) } }; f(100);",
+		"let f = fn() { a }; let a = 3; f()",
+		"let a = 1; let f = fn(a) { a * 10 }; f(4) + a",
+		"let a = 1; if (a > 0) { let a = 2; }; a",
+		"let a = 1; let f = fn() { let a = 2; a }; f() + a",
+		"let f = fn() { return 1; 2 }; f()",
+		"if (true) { let x = 4; }; x",
+		"if (false) { let x = 4; }; 1",
+		"let x = 2; if (x == 2) { 3 }",
+		"1 / (1 - 1)",
+		"1 + true",
+		"true + false",
+		"-true",
+		"let a = 1; a(2)",
+		"let a = 1; quote(a + 1)",
+		"return 1; 2",
+		"let z = 0; let f = fn(n) { if (n == z) { return true; } false }; f(0)",
+		"let a = 2; let f = fn() { for (a in 0..a + 3) { if (a > 3) { return a * a; } } }; f() + a",
+		"let i = 0; let n = 1; while (i < 5) { let i = i + 1; if (i > 3) { break; 7 } let n = n * 2; } n + i",
+	}
+
+	for _, code := range programs {
+		t.Run(code, func(t *testing.T) {
+			root := parse(t, code)
+
+			expected := evaluator.Eval(root, object.NewEnvironment())
+			actual := evaluator.Eval(Optimize(root, All), object.NewEnvironment())
+
+			assert.Equal(t, inspect(expected), inspect(actual))
+		})
+	}
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+
+	return obj.Inspect()
+}
+
+func Test_ParsePasses(t *testing.T) {
+	passes, err := ParsePasses("fold, inline")
+	require.NoError(t, err)
+	assert.Equal(t, Passes{FoldConstants: true, InlineLets: true}, passes)
+
+	passes, err = ParsePasses("all")
+	require.NoError(t, err)
+	assert.Equal(t, All, passes)
+
+	_, err = ParsePasses("fold,loops")
+	assert.EqualError(t, err, `unknown optimisation "loops"`)
+}
@@ -0,0 +1,258 @@
+package optimizer
+
+import (
+	"github.com/maybe-joe/monkey/ast"
+)
+
+// FoldConstants, replaces prefix and infix operators applied to integer and boolean
+// literals with their result, and ?? with the side used when its left is a literal.
+// Operators that would fail at runtime, such as a division by zero or adding booleans,
+// are left for the evaluator to report.
+func FoldConstants(node ast.Node) ast.Node {
+	return ast.Modify(node, func(n ast.Node) ast.Node {
+		switch n := n.(type) {
+		case *ast.PrefixNode:
+			if folded, ok := foldPrefix(n); ok {
+				return folded
+			}
+		case *ast.InfixNode:
+			if folded, ok := foldInfix(n); ok {
+				return folded
+			}
+		}
+
+		return n
+	})
+}
+
+func foldPrefix(n *ast.PrefixNode) (ast.Expression, bool) {
+	switch right := n.Right.(type) {
+	case *ast.IntegerNode:
+		switch n.Operator {
+		case "-":
+			return &ast.IntegerNode{Value: -right.Value, Span: n.Span}, true
+		case "!":
+			return &ast.BooleanNode{Value: false, Span: n.Span}, true
+		}
+	case *ast.BooleanNode:
+		if n.Operator == "!" {
+			return &ast.BooleanNode{Value: !right.Value, Span: n.Span}, true
+		}
+	}
+
+	return nil, false
+}
+
+func foldInfix(n *ast.InfixNode) (ast.Expression, bool) {
+	integer := func(value int64) (ast.Expression, bool) {
+		return &ast.IntegerNode{Value: value, Span: n.Span}, true
+	}
+	boolean := func(value bool) (ast.Expression, bool) {
+		return &ast.BooleanNode{Value: value, Span: n.Span}, true
+	}
+
+	// A literal on the left of ?? is known to be null or not.
+	if n.Operator == "??" {
+		switch n.Left.(type) {
+		case *ast.NullNode:
+			return n.Right, true
+		case *ast.IntegerNode, *ast.BooleanNode:
+			return n.Left, true
+		}
+		return nil, false
+	}
+
+	switch left := n.Left.(type) {
+	case *ast.IntegerNode:
+		right, ok := n.Right.(*ast.IntegerNode)
+		if !ok {
+			return nil, false
+		}
+
+		switch n.Operator {
+		case "+":
+			return integer(left.Value + right.Value)
+		case "-":
+			return integer(left.Value - right.Value)
+		case "*":
+			return integer(left.Value * right.Value)
+		case "/":
+			if right.Value != 0 {
+				return integer(left.Value / right.Value)
+			}
+		case "<":
+			return boolean(left.Value < right.Value)
+		case ">":
+			return boolean(left.Value > right.Value)
+		case "==":
+			return boolean(left.Value == right.Value)
+		case "!=":
+			return boolean(left.Value != right.Value)
+		}
+	case *ast.BooleanNode:
+		right, ok := n.Right.(*ast.BooleanNode)
+		if !ok {
+			return nil, false
+		}
+
+		switch n.Operator {
+		case "==":
+			return boolean(left.Value == right.Value)
+		case "!=":
+			return boolean(left.Value != right.Value)
+		}
+	}
+
+	return nil, false
+}
+
+// EliminateBranches, removes ifs and conditional expressions whose condition is a literal,
+// keeping only the branch taken.
+//
+// An if used as a statement is replaced by the statements of the branch taken, which
+// run in the same environment either way. An if used as an expression is replaced
+// when the branch taken is a single expression. Any other if is left alone, since
+// replacing it would change the value it produces.
+func EliminateBranches(node ast.Node) ast.Node {
+	return ast.Modify(node, func(n ast.Node) ast.Node {
+		switch n := n.(type) {
+		case *ast.IfNode:
+			if branch, ok := taken(n); ok {
+				if expr, ok := onlyExpression(branch); ok {
+					return expr
+				}
+			}
+		case *ast.ConditionalNode:
+			if truthy, ok := literalTruth(n.Condition); ok {
+				if truthy {
+					return n.Consequence
+				}
+				return n.Alternative
+			}
+		case *ast.BlockNode:
+			n.Statements = spliceBranches(n.Statements)
+		case *ast.RootNode:
+			n.Statements = spliceBranches(n.Statements)
+		}
+
+		return n
+	})
+}
+
+// taken, returns the branch of n that always runs, or false if it depends on the program.
+// The branch is nil when the condition is false and there is no else.
+func taken(n *ast.IfNode) (*ast.BlockNode, bool) {
+	truthy, ok := literalTruth(n.Condition)
+	switch {
+	case !ok:
+		return nil, false
+	case truthy:
+		return n.Consequence, true
+	default:
+		return n.Alternative, true
+	}
+}
+
+// literalTruth, returns whether condition is truthy, or false if it is not a literal.
+func literalTruth(condition ast.Expression) (truthy, ok bool) {
+	switch condition := condition.(type) {
+	case *ast.BooleanNode:
+		return condition.Value, true
+	case *ast.IntegerNode:
+		return true, true
+	case *ast.NullNode:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func onlyExpression(block *ast.BlockNode) (ast.Expression, bool) {
+	if block == nil || len(block.Statements) != 1 {
+		return nil, false
+	}
+
+	stmt, ok := block.Statements[0].(*ast.ExpressionStatementNode)
+	if !ok || stmt.Expression == nil {
+		return nil, false
+	}
+
+	return stmt.Expression, true
+}
+
+func spliceBranches(stmts []ast.Statement) []ast.Statement {
+	result := make([]ast.Statement, 0, len(stmts))
+
+	for i, stmt := range stmts {
+		branch, ok := statementBranch(stmt)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+
+		// The value of the last statement is the value of the list, keep the if
+		// unless the branch taken produces the same value.
+		last := i == len(stmts)-1
+
+		switch {
+		case branch == nil || len(branch.Statements) == 0:
+			if last {
+				result = append(result, stmt)
+			}
+		case !last || producesValue(branch):
+			result = append(result, branch.Statements...)
+		default:
+			result = append(result, stmt)
+		}
+	}
+
+	return result
+}
+
+func statementBranch(stmt ast.Statement) (*ast.BlockNode, bool) {
+	es, ok := stmt.(*ast.ExpressionStatementNode)
+	if !ok {
+		return nil, false
+	}
+
+	n, ok := es.Expression.(*ast.IfNode)
+	if !ok {
+		return nil, false
+	}
+
+	return taken(n)
+}
+
+func producesValue(block *ast.BlockNode) bool {
+	switch block.Statements[len(block.Statements)-1].(type) {
+	case *ast.ExpressionStatementNode, *ast.ReturnNode:
+		return true
+	default:
+		return false
+	}
+}
+
+// RemoveUnreachable, drops the statements following a return, break or continue in the same list.
+func RemoveUnreachable(node ast.Node) ast.Node {
+	return ast.Modify(node, func(n ast.Node) ast.Node {
+		switch n := n.(type) {
+		case *ast.BlockNode:
+			n.Statements = reachable(n.Statements)
+		case *ast.RootNode:
+			n.Statements = reachable(n.Statements)
+		}
+
+		return n
+	})
+}
+
+func reachable(stmts []ast.Statement) []ast.Statement {
+	for i, stmt := range stmts {
+		switch stmt.(type) {
+		case *ast.ReturnNode, *ast.BreakNode, *ast.ContinueNode:
+			return stmts[:i+1]
+		}
+	}
+
+	return stmts
+}
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/token"
+)
+
+// Edit, a change to code, the text in Span replaced with Text.
+type Edit struct {
+	Span token.Span
+	Text string
+}
+
+// Document, code kept parsed as it is edited, for editors that parse on every keystroke.
+//
+// The code is split into top level statements, each running up to the start of the next.
+// An edit is parsed from the statement before the first one it touches, since that statement
+// may now continue into the edit, until the parser reaches the start of one of the statements
+// after the edit. From there the code is as it was, so the old statements are kept.
+type Document struct {
+	code     string
+	root     *ast.RootNode
+	units    []unit
+	comments []token.Comment
+}
+
+// unit, a top level statement and the code from its start to the start of the next,
+// with the problems found parsing it.
+type unit struct {
+	// statement, a typed nil when the statement could not be parsed, as Parse returns it.
+	statement   ast.Statement
+	span        token.Span
+	diagnostics []diagnostics.Diagnostic
+}
+
+// NewDocument, parses code.
+func NewDocument(code string) *Document {
+	d := &Document{}
+	units, comments, _ := parseUnits(code, 0, func(int) bool { return false })
+	d.set(code, units, comments)
+
+	return d
+}
+
+// parseUnits, parses the statements in code from offset until the end of the code, or until
+// stop returns true for the offset the next statement starts at. It returns the statements
+// parsed, the comments before where it stopped and the offset it stopped at.
+func parseUnits(code string, offset int, stop func(int) bool) ([]unit, []token.Comment, int) {
+	tz := token.NewTokenizerAt(code, offset)
+	p := New(tz)
+
+	var units []unit
+	start := offset
+	for !p.current.Is(token.EOF) {
+		errs := len(p.diagnostics)
+
+		stmt := p.Statement()
+
+		// Skip the rest of a broken statement, as Parse does.
+		if len(p.diagnostics) > errs {
+			p.Until(token.SEMICOLON)
+		}
+
+		p.Next()
+
+		end := p.currentSpan.Start
+		units = append(units, unit{
+			statement:   stmt,
+			span:        token.Span{Start: start, End: end},
+			diagnostics: p.diagnostics[errs:len(p.diagnostics):len(p.diagnostics)],
+		})
+		start = end
+
+		if !p.current.Is(token.EOF) && stop(start) {
+			break
+		}
+	}
+
+	if p.current.Is(token.EOF) {
+		start = len(code)
+	}
+
+	// The tokenizer reads a token ahead, so may have passed comments beyond where parsing stopped.
+	var comments []token.Comment
+	for _, c := range tz.Comments() {
+		if c.Span.Start < start {
+			comments = append(comments, c)
+		}
+	}
+
+	return units, comments, start
+}
+
+func (d *Document) set(code string, units []unit, comments []token.Comment) {
+	d.code, d.units, d.comments = code, units, comments
+
+	d.root = &ast.RootNode{Span: token.Span{Start: 0, End: len(code)}}
+	for _, u := range units {
+		if u.statement != nil {
+			d.root.Statements = append(d.root.Statements, u.statement)
+		}
+	}
+}
+
+// Edit, makes the edit and parses the code again, returning the new tree and the statements
+// in it that were parsed anew. The other statements are those of the previous tree, their
+// spans shifted in place to where they now are, so the previous tree should not be used again.
+func (d *Document) Edit(e Edit) (*ast.RootNode, []ast.Node) {
+	start := max(0, min(e.Span.Start, len(d.code)))
+	end := max(start, min(e.Span.End, len(d.code)))
+
+	code := d.code[:start] + e.Text + d.code[end:]
+	delta := len(e.Text) - (end - start)
+
+	// The statement the edit starts in, parsing from the one before, which may
+	// continue into it if the edit changes its first token.
+	i := sort.Search(len(d.units), func(j int) bool { return d.units[j].span.End > start })
+	i = max(min(i, len(d.units)-1)-1, 0)
+
+	from := 0
+	if i < len(d.units) {
+		from = d.units[i].span.Start
+	}
+
+	// The statements starting after the edit, by where they now start.
+	after := map[int]int{}
+	for j := i + 1; j < len(d.units); j++ {
+		if s := d.units[j].span.Start; s >= end {
+			after[s+delta] = j
+		}
+	}
+
+	parsed, comments, stopped := parseUnits(code, from, func(offset int) bool {
+		_, ok := after[offset]
+		return ok
+	})
+
+	changed := []ast.Node{}
+	for _, u := range parsed {
+		if !isNil(u.statement) {
+			changed = append(changed, u.statement)
+		}
+	}
+
+	var kept []token.Comment
+	for _, c := range d.comments {
+		if c.Span.Start < from {
+			kept = append(kept, c)
+		}
+	}
+	kept = append(kept, comments...)
+
+	units := append(d.units[:i:i], parsed...)
+	if j, ok := after[stopped]; ok && stopped < len(code) {
+		for _, u := range d.units[j:] {
+			ast.Shift(u.statement, delta)
+
+			diags := make([]diagnostics.Diagnostic, len(u.diagnostics))
+			for k, diag := range u.diagnostics {
+				diags[k] = diag.Shift(delta)
+			}
+
+			units = append(units, unit{statement: u.statement, span: u.span.Shift(delta), diagnostics: diags})
+		}
+
+		for _, c := range d.comments {
+			if c.Span.Start >= stopped-delta {
+				kept = append(kept, token.Comment{Text: c.Text, Span: c.Span.Shift(delta)})
+			}
+		}
+	}
+
+	d.set(code, units, kept)
+	return d.root, changed
+}
+
+func isNil(stmt ast.Statement) bool {
+	if stmt == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(stmt)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+func (d *Document) Code() string {
+	return d.code
+}
+
+func (d *Document) Root() *ast.RootNode {
+	return d.root
+}
+
+// Diagnostics, returns every problem found parsing the code, as Parse would.
+func (d *Document) Diagnostics() []diagnostics.Diagnostic {
+	diags := []diagnostics.Diagnostic{}
+	for _, u := range d.units {
+		diags = append(diags, u.diagnostics...)
+	}
+
+	return diags
+}
+
+// Comments, returns the comments in the code.
+func (d *Document) Comments() []token.Comment {
+	return d.comments
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireParsed, checks the document holds what parsing its code from scratch gives.
+func requireParsed(t *testing.T, d *Document) {
+	t.Helper()
+
+	tz := token.NewTokenizer(d.Code())
+	p := New(tz)
+	root := p.Parse()
+
+	require.Empty(t, ast.Diff(root, d.Root()), "code %q", d.Code())
+	require.Equal(t, p.Diagnostics(), d.Diagnostics(), "code %q", d.Code())
+	require.Equal(t, tz.Comments(), d.Comments(), "code %q", d.Code())
+}
+
+func Test_Document_Edit(t *testing.T) {
+	const code = "let a = 1;\nlet b = 2;\n// three\nlet c = 3;\nlet d = 4;"
+
+	testcases := []struct {
+		name     string
+		given    Edit
+		expected string
+		// changed, the statements parsed again.
+		changed []string
+		// reused, the indexes of the old statements kept.
+		reused []int
+	}{
+		{
+			name:     "change a value",
+			given:    Edit{Span: token.Span{Start: 19, End: 20}, Text: "20"},
+			expected: "let a = 1;\nlet b = 20;\n// three\nlet c = 3;\nlet d = 4;",
+			changed:  []string{"let a = 1;", "let b = 20;"},
+			reused:   []int{2, 3},
+		},
+		{
+			name:     "insert a statement",
+			given:    Edit{Span: token.Span{Start: 31, End: 31}, Text: "let x = 0;\n"},
+			expected: "let a = 1;\nlet b = 2;\n// three\nlet x = 0;\nlet c = 3;\nlet d = 4;",
+			changed:  []string{"let b = 2;", "let x = 0;"},
+			reused:   []int{0, 2, 3},
+		},
+		{
+			name:     "delete a statement",
+			given:    Edit{Span: token.Span{Start: 11, End: 22}, Text: ""},
+			expected: "let a = 1;\n// three\nlet c = 3;\nlet d = 4;",
+			changed:  []string{"let a = 1;"},
+			reused:   []int{2, 3},
+		},
+		{
+			name:     "remove a semicolon",
+			given:    Edit{Span: token.Span{Start: 9, End: 10}, Text: ""},
+			expected: "let a = 1\nlet b = 2;\n// three\nlet c = 3;\nlet d = 4;",
+			changed:  []string{"let a = 1;"},
+			reused:   []int{1, 2, 3},
+		},
+		{
+			name:     "edit the last statement",
+			given:    Edit{Span: token.Span{Start: 50, End: 51}, Text: "a + b"},
+			expected: "let a = 1;\nlet b = 2;\n// three\nlet c = 3;\nlet d = a + b;",
+			changed:  []string{"let c = 3;", "let d = (a + b);"},
+			reused:   []int{0, 1},
+		},
+		{
+			name:     "break a statement",
+			given:    Edit{Span: token.Span{Start: 15, End: 16}, Text: ""},
+			expected: "let a = 1;\nlet  = 2;\n// three\nlet c = 3;\nlet d = 4;",
+			changed:  []string{"let a = 1;"},
+			reused:   []int{2, 3},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDocument(code)
+			before := d.Root().Statements
+
+			root, changed := d.Edit(tc.given)
+			assert.Equal(t, tc.expected, d.Code())
+			requireParsed(t, d)
+
+			var written []string
+			for _, n := range changed {
+				written = append(written, write(n))
+			}
+			assert.Equal(t, tc.changed, written)
+
+			for _, i := range tc.reused {
+				assert.Contains(t, root.Statements, before[i])
+			}
+		})
+	}
+}
+
+func write(n ast.Node) string {
+	var b bytes.Buffer
+	ast.NewWriter(&b).Write(n)
+	return strings.TrimSpace(b.String())
+}
+
+func Test_Document_Errors(t *testing.T) {
+	d := NewDocument("let a = ;\nlet b = 2;\nlet c = (1;")
+	requireParsed(t, d)
+	require.Len(t, d.Diagnostics(), 2)
+
+	// Fixing the first keeps the problem in the last, moved along.
+	d.Edit(Edit{Span: token.Span{Start: 8, End: 8}, Text: "100"})
+	requireParsed(t, d)
+	require.Len(t, d.Diagnostics(), 1)
+
+	d.Edit(Edit{Span: token.Span{Start: 34, End: 34}, Text: ")"})
+	requireParsed(t, d)
+	assert.Empty(t, d.Diagnostics())
+}
+
+// Test_Document_Typing, types a program a character at a time, then makes random edits,
+// checking the document matches a full parse after each.
+func Test_Document_Typing(t *testing.T) {
+	const program = `// adds things
+let add = fn(a, b) { a + b };
+let twice = fn(f, x) { f(f(x)) };
+let result = twice(fn(x) { add(x, 1) }, 5); // seven
+if (result > 5) { return result; } else { -result }
+let m = macro(x) { quote(unquote(x) * 2) };
+m(3)
+`
+
+	d := NewDocument("")
+	for i := range program {
+		d.Edit(Edit{Span: token.Span{Start: i, End: i}, Text: program[i : i+1]})
+		requireParsed(t, d)
+	}
+	require.Equal(t, program, d.Code())
+
+	fragments := []string{"", "x", " ", ";", "\n", "(", ")", "{", "}", "let ", "fn", "// ", "1 + ", "->", "-", "=", ":"}
+	random := rand.New(rand.NewSource(1))
+	for range 500 {
+		start := random.Intn(len(d.Code()) + 1)
+		end := min(len(d.Code()), start+random.Intn(4))
+		d.Edit(Edit{Span: token.Span{Start: start, End: end}, Text: fragments[random.Intn(len(fragments))]})
+		requireParsed(t, d)
+	}
+}
+
+func Test_Document_Continue(t *testing.T) {
+	d := NewDocument("a\nb")
+	require.Len(t, d.Root().Statements, 2)
+
+	// The minus makes the first statement continue into the second.
+	root, changed := d.Edit(Edit{Span: token.Span{Start: 2, End: 2}, Text: "-"})
+	requireParsed(t, d)
+	require.Len(t, root.Statements, 1)
+	assert.Equal(t, []string{"(a - b)"}, []string{write(changed[0])})
+}
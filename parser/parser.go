@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
 	"github.com/maybe-joe/monkey/token"
 )
 
@@ -11,14 +14,21 @@ var (
 	ErrExpectedIdentifier = "expected identifier after let"
 	ErrExpectedAssignment = "expected assignment after identifier"
 	ErrExpectedSemicolon  = "expected semicolon after expression"
+	ErrExpectedExpression = "expected expression"
+	ErrExpectedParameter  = "expected parameter name"
+	ErrExpectedType       = "expected type"
 )
 
 // Order of precedence
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x = y
+	CONDITIONAL // x ? y : z
+	COALESCE    // x ?? y
 	EQUALS      // ==
 	LESSGREATER // > or <
+	RANGE       // 0..10
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
@@ -28,15 +38,23 @@ const (
 // Precedences maps token types to their precedence level
 // for infix operators.
 var precedences = map[token.TokenType]int{
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.LPAREN:   CALL,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.QUESTION:        CONDITIONAL,
+	token.COALESCE:        COALESCE,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.DOTDOT:          RANGE,
+	token.LPAREN:          CALL,
 }
 
 type (
@@ -46,13 +64,19 @@ type (
 
 type Tokenizer interface {
 	Next() token.Token
+	// Span, the location of the token most recently returned by Next.
+	Span() token.Span
 }
 
 type Parser struct {
-	tokenizer Tokenizer
-	current   token.Token
-	next      token.Token
-	errors    []string
+	tokenizer   Tokenizer
+	current     token.Token
+	next        token.Token
+	currentSpan token.Span
+	nextSpan    token.Span
+	diagnostics []diagnostics.Diagnostic
+	// loops, the number of loops around the current token in the function being parsed.
+	loops int
 
 	prefixLookup map[token.TokenType]prefixFn
 	infixLookup  map[token.TokenType]infixFn
@@ -60,8 +84,8 @@ type Parser struct {
 
 func New(tokenizer Tokenizer) *Parser {
 	p := &Parser{
-		tokenizer: tokenizer,
-		errors:    []string{},
+		tokenizer:   tokenizer,
+		diagnostics: []diagnostics.Diagnostic{},
 	}
 
 	p.prefixLookup = map[token.TokenType]prefixFn{
@@ -71,21 +95,31 @@ func New(tokenizer Tokenizer) *Parser {
 		token.MINUS:    p.Prefix,
 		token.TRUE:     p.Boolean,
 		token.FALSE:    p.Boolean,
+		token.NULL:     p.Null,
 		token.LPAREN:   p.Group,
 		token.IF:       p.If,
 		token.FUNCTION: p.Function,
+		token.MACRO:    p.Macro,
 	}
 
 	p.infixLookup = map[token.TokenType]infixFn{
-		token.PLUS:     p.Infix,
-		token.MINUS:    p.Infix,
-		token.SLASH:    p.Infix,
-		token.ASTERISK: p.Infix,
-		token.EQ:       p.Infix,
-		token.NOT_EQ:   p.Infix,
-		token.LT:       p.Infix,
-		token.GT:       p.Infix,
-		token.LPAREN:   p.Call,
+		token.ASSIGN:          p.Assign,
+		token.PLUS_ASSIGN:     p.Assign,
+		token.MINUS_ASSIGN:    p.Assign,
+		token.ASTERISK_ASSIGN: p.Assign,
+		token.SLASH_ASSIGN:    p.Assign,
+		token.QUESTION:        p.Conditional,
+		token.COALESCE:        p.Infix,
+		token.PLUS:            p.Infix,
+		token.MINUS:           p.Infix,
+		token.SLASH:           p.Infix,
+		token.ASTERISK:        p.Infix,
+		token.EQ:              p.Infix,
+		token.NOT_EQ:          p.Infix,
+		token.LT:              p.Infix,
+		token.GT:              p.Infix,
+		token.DOTDOT:          p.Range,
+		token.LPAREN:          p.Call,
 	}
 
 	p.Next()
@@ -95,8 +129,11 @@ func New(tokenizer Tokenizer) *Parser {
 }
 
 func (p *Parser) Let() *ast.LetNode {
+	start := p.currentSpan.Start
+
 	// If the next token is not an identifier the code is invalid.
 	if !p.next.Is(token.IDENT) {
+		p.Unexpected(ErrExpectedIdentifier)
 		return nil
 	}
 
@@ -106,10 +143,22 @@ func (p *Parser) Let() *ast.LetNode {
 	// Create the identifier node.
 	id := ast.IdentifierNode{
 		Value: p.current.Literal,
+		Span:  p.currentSpan,
+	}
+
+	// An optional type annotation may follow the identifier.
+	var annotation *ast.TypeNode
+	if p.next.Is(token.COLON) {
+		p.Next()
+
+		if annotation = p.Type(); annotation == nil {
+			return nil
+		}
 	}
 
 	// Next we expect an assignment token.
 	if !p.next.Is(token.ASSIGN) {
+		p.Unexpected(ErrExpectedAssignment)
 		return nil
 	}
 
@@ -127,11 +176,15 @@ func (p *Parser) Let() *ast.LetNode {
 
 	return &ast.LetNode{
 		Identifier: &id,
+		Annotation: annotation,
 		Value:      expr,
+		Span:       p.SpanFrom(start),
 	}
 }
 
 func (p *Parser) Return() *ast.ReturnNode {
+	start := p.currentSpan.Start
+
 	// Advance to the expression token.
 	p.Next()
 
@@ -145,26 +198,33 @@ func (p *Parser) Return() *ast.ReturnNode {
 
 	return &ast.ReturnNode{
 		Value: expr,
+		Span:  p.SpanFrom(start),
 	}
 }
 
 func (p *Parser) If() ast.Expression {
+	start := p.currentSpan.Start
+
 	if !p.next.Is(token.LPAREN) {
+		p.Unexpected("expected '(' after if")
 		return nil
 	}
 
 	p.Next()
+	open := p.currentSpan
 	p.Next()
 
 	condition := p.Expression(LOWEST)
 
 	if !p.next.Is(token.RPAREN) {
+		p.Unclosed(open, token.LPAREN, token.RPAREN)
 		return nil
 	}
 
 	p.Next()
 
 	if !p.next.Is(token.LBRACE) {
+		p.Unexpected("expected '{' after if condition")
 		return nil
 	}
 
@@ -176,24 +236,190 @@ func (p *Parser) If() ast.Expression {
 	if p.next.Is(token.ELSE) {
 		p.Next()
 
-		if !p.next.Is(token.LBRACE) {
+		switch {
+		case p.next.Is(token.IF):
+			// else if, the alternative is a block holding only the next if.
+			p.Next()
+
+			next := p.If()
+			if next == nil {
+				return nil
+			}
+
+			span := next.Location()
+			alternative = &ast.BlockNode{
+				Statements: []ast.Statement{&ast.ExpressionStatementNode{Expression: next, Span: span}},
+				Span:       span,
+			}
+		case p.next.Is(token.LBRACE):
+			p.Next()
+
+			alternative = p.Block()
+		default:
+			p.Unexpected("expected '{' or 'if' after else")
 			return nil
 		}
-
-		p.Next()
-
-		alternative = p.Block()
 	}
 
 	return &ast.IfNode{
 		Condition:   condition,
 		Consequence: consequence,
 		Alternative: alternative,
+		Span:        p.SpanFrom(start),
+	}
+}
+
+func (p *Parser) While() *ast.WhileNode {
+	start := p.currentSpan.Start
+
+	if !p.next.Is(token.LPAREN) {
+		p.Unexpected("expected '(' after while")
+		return nil
+	}
+
+	p.Next()
+	open := p.currentSpan
+	p.Next()
+
+	condition := p.Expression(LOWEST)
+
+	if !p.next.Is(token.RPAREN) {
+		p.Unclosed(open, token.LPAREN, token.RPAREN)
+		return nil
+	}
+
+	p.Next()
+
+	if !p.next.Is(token.LBRACE) {
+		p.Unexpected("expected '{' after while condition")
+		return nil
+	}
+
+	p.Next()
+
+	p.loops++
+	body := p.Block()
+	p.loops--
+
+	// Possibly advance to the semicolon.
+	if p.next.Is(token.SEMICOLON) {
+		p.Next()
+	}
+
+	return &ast.WhileNode{
+		Condition: condition,
+		Body:      body,
+		Span:      p.SpanFrom(start),
 	}
 }
 
+func (p *Parser) For() *ast.ForNode {
+	start := p.currentSpan.Start
+
+	if !p.next.Is(token.LPAREN) {
+		p.Unexpected("expected '(' after for")
+		return nil
+	}
+
+	p.Next()
+	open := p.currentSpan
+
+	value := p.LoopVariable()
+	if value == nil {
+		return nil
+	}
+
+	// Only ranges can be iterated, which have no keys to bind a second name to.
+	if p.next.Is(token.COMMA) {
+		p.Error(diagnostics.Errorf(p.nextSpan, "for loops take one loop variable").
+			WithMessage("unexpected ','").
+			WithHint("only ranges can be iterated, and they have no keys"))
+		return nil
+	}
+
+	if !p.next.Is(token.IN) {
+		p.Unexpected("expected 'in' after loop variable")
+		return nil
+	}
+
+	p.Next()
+	p.Next()
+
+	iterable := p.Expression(LOWEST)
+
+	if !p.next.Is(token.RPAREN) {
+		p.Unclosed(open, token.LPAREN, token.RPAREN)
+		return nil
+	}
+
+	p.Next()
+
+	if !p.next.Is(token.LBRACE) {
+		p.Unexpected("expected '{' after for")
+		return nil
+	}
+
+	p.Next()
+
+	p.loops++
+	body := p.Block()
+	p.loops--
+
+	// Possibly advance to the semicolon.
+	if p.next.Is(token.SEMICOLON) {
+		p.Next()
+	}
+
+	return &ast.ForNode{
+		Value:    value,
+		Iterable: iterable,
+		Body:     body,
+		Span:     p.SpanFrom(start),
+	}
+}
+
+// LoopVariable, parses the name following the current token in a for.
+func (p *Parser) LoopVariable() *ast.IdentifierNode {
+	if !p.next.Is(token.IDENT) {
+		p.Unexpected("expected loop variable")
+		return nil
+	}
+
+	p.Next()
+	return &ast.IdentifierNode{Value: p.current.Literal, Span: p.currentSpan}
+}
+
+func (p *Parser) Break() *ast.BreakNode {
+	start := p.Jump("break")
+	return &ast.BreakNode{Span: p.SpanFrom(start)}
+}
+
+func (p *Parser) Continue() *ast.ContinueNode {
+	start := p.Jump("continue")
+	return &ast.ContinueNode{Span: p.SpanFrom(start)}
+}
+
+// Jump, parses a break or continue, reporting it if it is not inside a loop.
+// It returns where the statement starts.
+func (p *Parser) Jump(keyword string) int {
+	start := p.currentSpan.Start
+
+	if p.loops == 0 {
+		p.Error(diagnostics.Errorf(p.currentSpan, "'%s' outside of a loop", keyword).
+			WithMessage("not in a loop"))
+	}
+
+	// Possibly advance to the semicolon.
+	if p.next.Is(token.SEMICOLON) {
+		p.Next()
+	}
+
+	return start
+}
+
 func (p *Parser) Block() *ast.BlockNode {
 	stmts := []ast.Statement{}
+	open := p.currentSpan
 
 	p.Next()
 
@@ -204,43 +430,58 @@ func (p *Parser) Block() *ast.BlockNode {
 		p.Next()
 	}
 
+	if p.current.Is(token.EOF) {
+		p.Unclosed(open, token.LBRACE, token.RBRACE)
+	}
+
 	return &ast.BlockNode{
 		Statements: stmts,
+		Span:       p.SpanFrom(open.Start),
 	}
 }
 
 func (p *Parser) Identifier() ast.Expression {
 	return &ast.IdentifierNode{
 		Value: p.current.Literal,
+		Span:  p.currentSpan,
 	}
 }
 
 func (p *Parser) Integer() ast.Expression {
 	i, err := strconv.ParseInt(p.current.Literal, 0, 64)
 	if err != nil {
-		p.errors = append(p.errors, "could not parse "+p.current.Literal+" as integer")
+		p.Error(diagnostics.Errorf(p.currentSpan, "could not parse %s as integer", p.current.Literal))
 		return nil
 	}
 
 	return &ast.IntegerNode{
 		Value: i,
+		Span:  p.currentSpan,
 	}
 }
 
 func (p *Parser) Boolean() ast.Expression {
 	return &ast.BooleanNode{
 		Value: p.current.Is(token.TRUE),
+		Span:  p.currentSpan,
 	}
 }
 
+func (p *Parser) Null() ast.Expression {
+	return &ast.NullNode{Span: p.currentSpan}
+}
+
 func (p *Parser) Call(function ast.Expression) ast.Expression {
 	return &ast.CallNode{
 		Function:  function,
 		Arguments: p.Arguments(),
+		Span:      p.SpanFrom(function.Location().Start),
 	}
 }
 
 func (p *Parser) Arguments() []ast.Expression {
+	open := p.currentSpan
+
 	if p.next.Is(token.RPAREN) {
 		p.Next()
 		return nil
@@ -259,6 +500,7 @@ func (p *Parser) Arguments() []ast.Expression {
 	}
 
 	if !p.next.Is(token.RPAREN) {
+		p.Unclosed(open, token.LPAREN, token.RPAREN)
 		return nil
 	}
 
@@ -267,61 +509,223 @@ func (p *Parser) Arguments() []ast.Expression {
 }
 
 func (p *Parser) Function() ast.Expression {
-	if !p.next.Is(token.LPAREN) {
+	start := p.currentSpan.Start
+
+	sig, ok := p.Signature("fn")
+	if !ok {
 		return nil
 	}
 
-	p.Next()
+	return &ast.FunctionNode{
+		Parameters:     sig.parameters,
+		ParameterTypes: sig.types,
+		ReturnType:     sig.returns,
+		Body:           sig.body,
+		Span:           p.SpanFrom(start),
+	}
+}
 
-	parameters := p.Parameters()
+func (p *Parser) Macro() ast.Expression {
+	start := p.currentSpan.Start
 
-	if !p.next.Is(token.LBRACE) {
+	sig, ok := p.Signature("macro")
+	if !ok {
 		return nil
 	}
 
+	// Macros work on code rather than values, so there is nothing to annotate.
+	for _, annotation := range slices.Concat(sig.types, []*ast.TypeNode{sig.returns}) {
+		if annotation != nil {
+			p.Error(diagnostics.Errorf(annotation.Span, "macros cannot have type annotations"))
+			return nil
+		}
+	}
+
+	return &ast.MacroNode{
+		Parameters: sig.parameters,
+		Body:       sig.body,
+		Span:       p.SpanFrom(start),
+	}
+}
+
+// signature, the parts of a function or macro following its keyword.
+type signature struct {
+	parameters []*ast.IdentifierNode
+	// types, the annotations of the parameters, nil if none are annotated.
+	types   []*ast.TypeNode
+	returns *ast.TypeNode
+	body    *ast.BlockNode
+}
+
+// Signature, parses the parameters, return type and body following the fn or macro keyword.
+func (p *Parser) Signature(keyword string) (signature, bool) {
+	var sig signature
+
+	if !p.next.Is(token.LPAREN) {
+		p.Unexpected("expected '(' after " + keyword)
+		return sig, false
+	}
+
 	p.Next()
 
-	body := p.Block()
+	var ok bool
+	if sig.parameters, sig.types, ok = p.Parameters(); !ok {
+		return sig, false
+	}
 
-	return &ast.FunctionNode{
-		Parameters: parameters,
-		Body:       body,
+	if p.next.Is(token.ARROW) {
+		p.Next()
+
+		if sig.returns = p.Type(); sig.returns == nil {
+			return sig, false
+		}
+	}
+
+	if !p.next.Is(token.LBRACE) {
+		p.Unexpected("expected '{' after parameters")
+		return sig, false
 	}
+
+	p.Next()
+
+	// A loop around the function does not continue into its body.
+	loops := p.loops
+	p.loops = 0
+	sig.body = p.Block()
+	p.loops = loops
+
+	return sig, true
 }
 
-func (p *Parser) Parameters() []*ast.IdentifierNode {
+// Parameters, parses a parenthesised list of parameters, each with an optional annotation.
+// The annotations are returned lined up with the parameters, or nil if there are none.
+func (p *Parser) Parameters() ([]*ast.IdentifierNode, []*ast.TypeNode, bool) {
+	open := p.currentSpan
+
 	if p.next.Is(token.RPAREN) {
 		p.Next()
-		return nil
+		return nil, nil, true
+	}
+
+	identifiers := []*ast.IdentifierNode{}
+	types := []*ast.TypeNode{}
+	annotated := false
+
+	for {
+		if !p.next.Is(token.IDENT) {
+			p.Unexpected(ErrExpectedParameter)
+			return nil, nil, false
+		}
+
+		p.Next()
+		identifiers = append(identifiers, &ast.IdentifierNode{Value: p.current.Literal, Span: p.currentSpan})
+
+		var annotation *ast.TypeNode
+		if p.next.Is(token.COLON) {
+			p.Next()
+
+			if annotation = p.Type(); annotation == nil {
+				return nil, nil, false
+			}
+			annotated = true
+		}
+		types = append(types, annotation)
+
+		if !p.next.Is(token.COMMA) {
+			break
+		}
+
+		p.Next()
+	}
+
+	if !p.next.Is(token.RPAREN) {
+		p.Unclosed(open, token.LPAREN, token.RPAREN)
+		return nil, nil, false
 	}
 
 	p.Next()
 
-	identifiers := []*ast.IdentifierNode{
-		{Value: p.current.Literal},
+	if !annotated {
+		types = nil
 	}
 
-	for p.next.Is(token.COMMA) {
+	return identifiers, types, true
+}
+
+// Type, parses the type annotation starting at the next token, either a name such as int
+// or a function type such as fn(int, bool) -> int.
+func (p *Parser) Type() *ast.TypeNode {
+	switch {
+	case p.next.Is(token.IDENT):
 		p.Next()
+		return &ast.TypeNode{Name: p.current.Literal, Span: p.currentSpan}
+	case p.next.Is(token.FUNCTION):
 		p.Next()
-		identifiers = append(identifiers, &ast.IdentifierNode{Value: p.current.Literal})
+	default:
+		p.Unexpected(ErrExpectedType)
+		return nil
 	}
 
-	if !p.next.Is(token.RPAREN) {
+	start := p.currentSpan.Start
+
+	if !p.next.Is(token.LPAREN) {
+		p.Unexpected("expected '(' after fn")
 		return nil
 	}
 
 	p.Next()
+	open := p.currentSpan
+
+	var params []*ast.TypeNode
+	if p.next.Is(token.RPAREN) {
+		p.Next()
+	} else {
+		for {
+			param := p.Type()
+			if param == nil {
+				return nil
+			}
+			params = append(params, param)
+
+			if !p.next.Is(token.COMMA) {
+				break
+			}
+
+			p.Next()
+		}
 
-	return identifiers
+		if !p.next.Is(token.RPAREN) {
+			p.Unclosed(open, token.LPAREN, token.RPAREN)
+			return nil
+		}
+
+		p.Next()
+	}
+
+	if !p.next.Is(token.ARROW) {
+		p.Unexpected("expected '->' after parameter types")
+		return nil
+	}
+
+	p.Next()
+
+	returns := p.Type()
+	if returns == nil {
+		return nil
+	}
+
+	return &ast.TypeNode{Parameters: params, Return: returns, Span: p.SpanFrom(start)}
 }
 
 func (p *Parser) Group() ast.Expression {
+	open := p.currentSpan
+
 	p.Next()
 
 	expr := p.Expression(LOWEST)
 
 	if !p.next.Is(token.RPAREN) {
+		p.Unclosed(open, token.LPAREN, token.RPAREN)
 		return nil
 	}
 
@@ -334,10 +738,12 @@ func (p *Parser) Prefix() ast.Expression {
 	expr := &ast.PrefixNode{
 		Operator: p.current.String(),
 	}
+	start := p.currentSpan.Start
 
 	p.Next()
 
 	expr.Right = p.Expression(PREFIX)
+	expr.Span = p.SpanFrom(start)
 
 	return expr
 }
@@ -355,17 +761,83 @@ func (p *Parser) Infix(left ast.Expression) ast.Expression {
 
 	p.Next()
 	expr.Right = p.Expression(precedence)
+	expr.Span = p.SpanFrom(left.Location().Start)
 
 	return expr
 }
 
+// Conditional, parses cond ? a : b. The alternative groups to the right, so
+// a ? b : c ? d : e reads as a ? b : (c ? d : e).
+func (p *Parser) Conditional(condition ast.Expression) ast.Expression {
+	question := p.currentSpan
+
+	p.Next()
+	consequence := p.Expression(LOWEST)
+
+	if !p.next.Is(token.COLON) {
+		p.Error(diagnostics.Errorf(p.nextSpan, "expected ':' in conditional expression, found %s", Describe(p.next)).
+			WithMessage("expected ':'").
+			WithLabel(question, "conditional starts here"))
+		return nil
+	}
+
+	p.Next()
+	p.Next()
+
+	return &ast.ConditionalNode{
+		Condition:   condition,
+		Consequence: consequence,
+		Alternative: p.Expression(CONDITIONAL - 1),
+		Span:        p.SpanFrom(condition.Location().Start),
+	}
+}
+
+// Assign, parses an assignment to the name on the left. Assignments group to the right,
+// so a = b = 1 assigns 1 to b and then the result to a.
+func (p *Parser) Assign(left ast.Expression) ast.Expression {
+	operator := p.current.String()
+
+	p.Next()
+	value := p.Expression(ASSIGN - 1)
+
+	name, ok := left.(*ast.IdentifierNode)
+	if !ok {
+		p.Error(diagnostics.Errorf(left.Location(), "invalid assignment target").
+			WithMessage("cannot be assigned to").
+			WithHint("only names can be assigned to"))
+		return nil
+	}
+
+	return &ast.AssignNode{
+		Name:     name,
+		Operator: operator,
+		Value:    value,
+		Span:     p.SpanFrom(left.Location().Start),
+	}
+}
+
+func (p *Parser) Range(start ast.Expression) ast.Expression {
+	p.Next()
+
+	return &ast.RangeNode{
+		Start: start,
+		End:   p.Expression(RANGE),
+		Span:  p.SpanFrom(start.Location().Start),
+	}
+}
+
 func (p *Parser) Expression(precedence int) ast.Expression {
 	prefix, ok := p.prefixLookup[p.current.Type]
 	if !ok {
+		p.Error(diagnostics.Errorf(p.currentSpan, "%s, found %s", ErrExpectedExpression, Describe(p.current)).
+			WithMessage(ErrExpectedExpression))
 		return nil
 	}
 
 	expr := prefix()
+	if expr == nil {
+		return nil
+	}
 
 	for !p.next.Is(token.SEMICOLON) && precedence < precedences[p.next.Type] {
 		infix, ok := p.infixLookup[p.next.Type]
@@ -376,12 +848,17 @@ func (p *Parser) Expression(precedence int) ast.Expression {
 		p.Next()
 
 		expr = infix(expr)
+		if expr == nil {
+			return nil
+		}
 	}
 
 	return expr
 }
 
 func (p *Parser) ExpressionStatement() *ast.ExpressionStatementNode {
+	start := p.currentSpan.Start
+
 	expr := p.Expression(LOWEST)
 
 	if p.next.Is(token.SEMICOLON) {
@@ -390,6 +867,7 @@ func (p *Parser) ExpressionStatement() *ast.ExpressionStatementNode {
 
 	return &ast.ExpressionStatementNode{
 		Expression: expr,
+		Span:       p.SpanFrom(start),
 	}
 }
 
@@ -401,12 +879,22 @@ func (p *Parser) Statement() ast.Statement {
 		return p.Let()
 	case token.RETURN:
 		return p.Return()
+	case token.WHILE:
+		return p.While()
+	case token.FOR:
+		return p.For()
+	case token.BREAK:
+		return p.Break()
+	case token.CONTINUE:
+		return p.Continue()
 	}
 }
 
 func (p *Parser) Next() {
 	p.current = p.next
+	p.currentSpan = p.nextSpan
 	p.next = p.tokenizer.Next()
+	p.nextSpan = p.tokenizer.Span()
 }
 
 func (p *Parser) Until(typ token.TokenType) {
@@ -415,20 +903,81 @@ func (p *Parser) Until(typ token.TokenType) {
 	}
 }
 
+// SpanFrom, returns the span from start to the end of the current token.
+func (p *Parser) SpanFrom(start int) token.Span {
+	return token.Span{Start: start, End: p.currentSpan.End}
+}
+
+// Error, records a diagnostic.
+func (p *Parser) Error(d diagnostics.Diagnostic) {
+	p.diagnostics = append(p.diagnostics, d)
+}
+
+// Unexpected, records that the next token is not the one expected.
+func (p *Parser) Unexpected(expected string) {
+	p.Error(diagnostics.Errorf(p.nextSpan, "%s, found %s", expected, Describe(p.next)))
+}
+
+// Unclosed, records that the next token should have closed the delimiter at open.
+func (p *Parser) Unclosed(open token.Span, opening, closing token.TokenType) {
+	p.Error(diagnostics.Errorf(p.nextSpan, "expected '%s', found %s", closing, Describe(p.next)).
+		WithMessage("expected '"+string(closing)+"'").
+		WithLabel(open, "unclosed '"+string(opening)+"'").
+		WithHint("expected '" + string(closing) + "' to close '" + string(opening) + "' opened here"))
+}
+
 func (p *Parser) Parse() *ast.RootNode {
 	root := &ast.RootNode{}
 
 	for p.current.Type != token.EOF {
+		errs := len(p.diagnostics)
+
 		if stmt := p.Statement(); stmt != nil {
 			root.Statements = append(root.Statements, stmt)
 		}
 
+		// Skip the rest of a broken statement rather than reporting every token in it.
+		if len(p.diagnostics) > errs {
+			p.Until(token.SEMICOLON)
+		}
+
 		p.Next()
 	}
 
+	root.Span = p.SpanFrom(0)
+
 	return root
 }
 
+// Diagnostics, returns every problem found while parsing.
+func (p *Parser) Diagnostics() []diagnostics.Diagnostic {
+	return p.diagnostics
+}
+
+// Errors, returns the message of every problem found while parsing.
 func (p *Parser) Errors() []string {
-	return p.errors
+	errs := make([]string, 0, len(p.diagnostics))
+	for _, d := range p.diagnostics {
+		errs = append(errs, d.Message)
+	}
+
+	return errs
+}
+
+// Describe, returns how a token is referred to in error messages.
+func Describe(t token.Token) string {
+	switch t.Type {
+	case token.EOF:
+		return "end of input"
+	case token.IDENT:
+		return "identifier '" + t.Literal + "'"
+	case token.INT:
+		return "integer " + t.Literal
+	case token.FUNCTION:
+		return "'fn'"
+	case token.ILLEGAL:
+		return "'" + t.Literal + "'"
+	default:
+		return "'" + strings.ToLower(string(t.Type)) + "'"
+	}
 }
@@ -1,13 +1,27 @@
 package parser
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
 	"github.com/maybe-joe/monkey/token"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// parse, parses code that is expected to be valid.
+func parse(t *testing.T, code string) *ast.RootNode {
+	t.Helper()
+
+	p := New(token.NewTokenizer(code))
+	root := p.Parse()
+	require.Empty(t, p.Errors())
+
+	return root
+}
+
 func Test_Program(t *testing.T) {
 	given := `
 		let x = 5;
@@ -41,8 +55,8 @@ func Test_Program(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_Let(t *testing.T) {
@@ -64,8 +78,8 @@ func Test_Let(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_Return(t *testing.T) {
@@ -85,8 +99,8 @@ func Test_Return(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_Prefix(t *testing.T) {
@@ -112,8 +126,8 @@ func Test_Prefix(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_Infix(t *testing.T) {
@@ -189,8 +203,8 @@ func Test_Infix(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_Boolean(t *testing.T) {
@@ -210,8 +224,8 @@ func Test_Boolean(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_Group(t *testing.T) {
@@ -235,8 +249,8 @@ func Test_Group(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_If(t *testing.T) {
@@ -272,8 +286,191 @@ func Test_If(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+}
+
+func Test_ElseIf(t *testing.T) {
+	given := `if (x < 0) { a } else if (x > 0) { b } else { c }`
+
+	expected := ast.Root(ast.ExpressionStatement(ast.If(
+		ast.Infix(ast.Identifier("x"), "<", ast.Integer(0)),
+		ast.Block(ast.ExpressionStatement(ast.Identifier("a"))),
+		ast.Block(ast.ExpressionStatement(ast.If(
+			ast.Infix(ast.Identifier("x"), ">", ast.Integer(0)),
+			ast.Block(ast.ExpressionStatement(ast.Identifier("b"))),
+			ast.Block(ast.ExpressionStatement(ast.Identifier("c"))),
+		))),
+	)))
+
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+
+	var buf bytes.Buffer
+	ast.NewWriter(&buf).Write(actual)
+	assert.Equal(t, "if (x < 0) {\n\ta\n} else if (x > 0) {\n\tb\n} else {\n\tc\n}", buf.String())
+}
+
+func Test_Conditional(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{given: "a ? b : c", expected: "(a ? b : c)"},
+		{given: "a ? b : c ? d : e", expected: "(a ? b : (c ? d : e))"},
+		{given: "a ? b ? c : d : e", expected: "(a ? (b ? c : d) : e)"},
+		{given: "x < 0 ? -x : x", expected: "((x < 0) ? -x : x)"},
+		{given: "a ?? b ? c ?? d : e ?? f", expected: "((a ?? b) ? (c ?? d) : (e ?? f))"},
+		{given: "x = a ? 1 : 2", expected: "(x = (a ? 1 : 2))"},
+		{given: "a ? x = 1 : 2", expected: "(a ? (x = 1) : 2)"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.given, func(t *testing.T) {
+			var buf bytes.Buffer
+			ast.NewWriter(&buf).Write(parse(t, tc.given))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func Test_While(t *testing.T) {
+	given := `
+		while (x < 10) { if (x > 5) { break; } continue; }
+	`
+
+	expected := &ast.RootNode{
+		Statements: []ast.Statement{
+			&ast.WhileNode{
+				Condition: &ast.InfixNode{
+					Left:     &ast.IdentifierNode{Value: "x"},
+					Operator: "<",
+					Right:    &ast.IntegerNode{Value: 10},
+				},
+				Body: &ast.BlockNode{
+					Statements: []ast.Statement{
+						&ast.ExpressionStatementNode{
+							Expression: &ast.IfNode{
+								Condition: &ast.InfixNode{
+									Left:     &ast.IdentifierNode{Value: "x"},
+									Operator: ">",
+									Right:    &ast.IntegerNode{Value: 5},
+								},
+								Consequence: &ast.BlockNode{
+									Statements: []ast.Statement{&ast.BreakNode{}},
+								},
+							},
+						},
+						&ast.ContinueNode{},
+					},
+				},
+			},
+		},
+	}
+
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+
+	loop := actual.Statements[0].(*ast.WhileNode)
+	span := loop.Body.Statements[1].Location()
+	assert.Equal(t, "continue;", given[span.Start:span.End])
+}
+
+func Test_For(t *testing.T) {
+	given := `
+		for (i in 0..n + 1) { continue; }
+		for (x in xs) { break; }
+	`
+
+	expected := &ast.RootNode{
+		Statements: []ast.Statement{
+			&ast.ForNode{
+				Value: &ast.IdentifierNode{Value: "i"},
+				Iterable: &ast.RangeNode{
+					Start: &ast.IntegerNode{Value: 0},
+					End: &ast.InfixNode{
+						Left:     &ast.IdentifierNode{Value: "n"},
+						Operator: "+",
+						Right:    &ast.IntegerNode{Value: 1},
+					},
+				},
+				Body: &ast.BlockNode{Statements: []ast.Statement{&ast.ContinueNode{}}},
+			},
+			&ast.ForNode{
+				Value:    &ast.IdentifierNode{Value: "x"},
+				Iterable: &ast.IdentifierNode{Value: "xs"},
+				Body:     &ast.BlockNode{Statements: []ast.Statement{&ast.BreakNode{}}},
+			},
+		},
+	}
+
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+}
+
+func Test_Range(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{given: "0..10", expected: "(0..10)"},
+		{given: "a - 1..b * 2", expected: "((a - 1)..(b * 2))"},
+		{given: "0..n < m", expected: "((0..n) < m)"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.given, func(t *testing.T) {
+			var buf bytes.Buffer
+			ast.NewWriter(&buf).Write(parse(t, tc.given))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func Test_Assign(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{given: "x = 1", expected: "(x = 1)"},
+		{given: "x = x + 1", expected: "(x = (x + 1))"},
+		{given: "a = b = c", expected: "(a = (b = c))"},
+		{given: "x += y * 2", expected: "(x += (y * 2))"},
+		{given: "x -= 1", expected: "(x -= 1)"},
+		{given: "x *= 2", expected: "(x *= 2)"},
+		{given: "x /= 2", expected: "(x /= 2)"},
+		{given: "f(x = 1)", expected: "f((x = 1))"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.given, func(t *testing.T) {
+			var buf bytes.Buffer
+			ast.NewWriter(&buf).Write(parse(t, tc.given))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func Test_Coalesce(t *testing.T) {
+	testcases := []struct {
+		given    string
+		expected string
+	}{
+		{given: "null", expected: "null"},
+		{given: "a ?? 1", expected: "(a ?? 1)"},
+		{given: "a ?? b ?? c", expected: "((a ?? b) ?? c)"},
+		{given: "a ?? b == c", expected: "(a ?? (b == c))"},
+		{given: "a + b ?? c * d", expected: "((a + b) ?? (c * d))"},
+		{given: "x = a ?? null", expected: "(x = (a ?? null))"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.given, func(t *testing.T) {
+			var buf bytes.Buffer
+			ast.NewWriter(&buf).Write(parse(t, tc.given))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
 }
 
 func Test_Function(t *testing.T) {
@@ -305,8 +502,8 @@ func Test_Function(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
 }
 
 func Test_Call(t *testing.T) {
@@ -337,6 +534,208 @@ func Test_Call(t *testing.T) {
 		},
 	}
 
-	actual := New(token.NewTokenizer(given)).Parse()
-	assert.Equal(t, expected, actual)
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+}
+
+func Test_Macro(t *testing.T) {
+	given := `
+		macro(x, y) { quote(unquote(y) - unquote(x)); }
+	`
+
+	expected := &ast.RootNode{
+		Statements: []ast.Statement{
+			&ast.ExpressionStatementNode{
+				Expression: &ast.MacroNode{
+					Parameters: []*ast.IdentifierNode{
+						{Value: "x"},
+						{Value: "y"},
+					},
+					Body: &ast.BlockNode{
+						Statements: []ast.Statement{
+							&ast.ExpressionStatementNode{
+								Expression: &ast.CallNode{
+									Function: &ast.IdentifierNode{Value: "quote"},
+									Arguments: []ast.Expression{
+										&ast.InfixNode{
+											Left: &ast.CallNode{
+												Function:  &ast.IdentifierNode{Value: "unquote"},
+												Arguments: []ast.Expression{&ast.IdentifierNode{Value: "y"}},
+											},
+											Operator: "-",
+											Right: &ast.CallNode{
+												Function:  &ast.IdentifierNode{Value: "unquote"},
+												Arguments: []ast.Expression{&ast.IdentifierNode{Value: "x"}},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+}
+
+func Test_Annotations(t *testing.T) {
+	given := `
+		let x: int = 5;
+		fn(f: fn(int, bool) -> int, n) -> fn() -> bool { f }
+	`
+
+	expected := &ast.RootNode{
+		Statements: []ast.Statement{
+			&ast.LetNode{
+				Identifier: &ast.IdentifierNode{Value: "x"},
+				Annotation: &ast.TypeNode{Name: "int"},
+				Value:      &ast.IntegerNode{Value: 5},
+			},
+			&ast.ExpressionStatementNode{
+				Expression: &ast.FunctionNode{
+					Parameters: []*ast.IdentifierNode{{Value: "f"}, {Value: "n"}},
+					ParameterTypes: []*ast.TypeNode{
+						{Parameters: []*ast.TypeNode{{Name: "int"}, {Name: "bool"}}, Return: &ast.TypeNode{Name: "int"}},
+						nil,
+					},
+					ReturnType: &ast.TypeNode{Return: &ast.TypeNode{Name: "bool"}},
+					Body: &ast.BlockNode{
+						Statements: []ast.Statement{
+							&ast.ExpressionStatementNode{Expression: &ast.IdentifierNode{Value: "f"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	actual := parse(t, given)
+	assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+
+	var buf bytes.Buffer
+	ast.NewWriter(&buf).Write(actual)
+	assert.Equal(t, "let x: int = 5;\nfn(f: fn(int, bool) -> int, n) -> fn() -> bool {\n\tf\n}", buf.String())
+}
+
+func Test_Spans(t *testing.T) {
+	given := "let add = fn(x) { x + 1; };\nadd(2);"
+
+	root := New(token.NewTokenizer(given)).Parse()
+	require.Len(t, root.Statements, 2)
+
+	text := func(n ast.Node) string {
+		span := n.Location()
+		return given[span.Start:span.End]
+	}
+
+	let := root.Statements[0].(*ast.LetNode)
+	fn := let.Value.(*ast.FunctionNode)
+	body := fn.Body.Statements[0].(*ast.ExpressionStatementNode)
+	call := root.Statements[1].(*ast.ExpressionStatementNode)
+
+	assert.Equal(t, given, text(root))
+	assert.Equal(t, "let add = fn(x) { x + 1; };", text(let))
+	assert.Equal(t, "add", text(let.Identifier))
+	assert.Equal(t, "fn(x) { x + 1; }", text(fn))
+	assert.Equal(t, "x", text(fn.Parameters[0]))
+	assert.Equal(t, "{ x + 1; }", text(fn.Body))
+	assert.Equal(t, "x + 1;", text(body))
+	assert.Equal(t, "x + 1", text(body.Expression))
+	assert.Equal(t, "add(2);", text(call))
+	assert.Equal(t, "add(2)", text(call.Expression))
+}
+
+func Test_Diagnostics(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected []string
+	}{
+		{name: "let without identifier", given: "let = 5;", expected: []string{"expected identifier after let, found '='"}},
+		{name: "let without assignment", given: "let x 5;", expected: []string{"expected assignment after identifier, found integer 5"}},
+		{name: "missing expression", given: "let x = ;", expected: []string{"expected expression, found ';'"}},
+		{name: "unclosed group", given: "(1 + 2;", expected: []string{"expected ')', found ';'"}},
+		{name: "unclosed call", given: "add(1, 2", expected: []string{"expected ')', found end of input"}},
+		{name: "unclosed block", given: "fn() { 1", expected: []string{"expected '}', found end of input"}},
+		{name: "bad parameter", given: "fn(1) {}", expected: []string{"expected parameter name, found integer 1"}},
+		{name: "if without paren", given: "if x {}", expected: []string{"expected '(' after if, found identifier 'x'"}},
+		{name: "let without type", given: "let x: = 5;", expected: []string{"expected type, found '='"}},
+		{name: "function type without arrow", given: "let f: fn(int) = 5;", expected: []string{"expected '->' after parameter types, found '='"}},
+		{name: "unclosed function type", given: "fn(f: fn(int -> int) {}", expected: []string{"expected ')', found '->'"}},
+		{name: "return type without type", given: "fn() -> { 1 }", expected: []string{"expected type, found '{'"}},
+		{name: "annotated macro", given: "macro(x: int) { x }", expected: []string{"macros cannot have type annotations"}},
+		{name: "while without paren", given: "while x {}", expected: []string{"expected '(' after while, found identifier 'x'"}},
+		{name: "break outside loop", given: "break;", expected: []string{"'break' outside of a loop"}},
+		{name: "continue outside loop", given: "if (x) { continue; }", expected: []string{"'continue' outside of a loop"}},
+		{name: "break in function in loop", given: "while (true) { fn() { break; } }", expected: []string{"'break' outside of a loop"}},
+		{name: "for without in", given: "for (x xs) {}", expected: []string{"expected 'in' after loop variable, found identifier 'xs'"}},
+		{name: "for without variable", given: "for (1 in xs) {}", expected: []string{"expected loop variable, found integer 1"}},
+		{name: "for with two variables", given: "for (k, v in xs) {}", expected: []string{"for loops take one loop variable"}},
+		{name: "break in for", given: "for (x in xs) { break; } break;", expected: []string{"'break' outside of a loop"}},
+		{name: "assign to expression", given: "a + b = 1;", expected: []string{"invalid assignment target"}},
+		{name: "assign to call", given: "f() += 1;", expected: []string{"invalid assignment target"}},
+		{name: "else without block", given: "if (x) { 1 } else 2", expected: []string{"expected '{' or 'if' after else, found integer 2"}},
+		{name: "broken else if", given: "if (x) { 1 } else if y { 2 }", expected: []string{"expected '(' after if, found identifier 'y'"}},
+		{name: "conditional without colon", given: "a ? b;", expected: []string{"expected ':' in conditional expression, found ';'"}},
+		{name: "conditional without colon then group", given: "a ? ) (1)", expected: []string{"expected expression, found ')'", "expected ':' in conditional expression, found '('"}},
+		{name: "conditional without colon then operator", given: "a ? ] + 1", expected: []string{"expected expression, found ']'", "expected ':' in conditional expression, found '+'"}},
+		{name: "bad target then group", given: "1 = ) (1)", expected: []string{"expected expression, found ')'", "invalid assignment target"}},
+		{name: "bad target then operator", given: "1 = ] + 1", expected: []string{"expected expression, found ']'", "invalid assignment target"}},
+		{name: "one error per statement", given: "let = 1 2 3; let y = );", expected: []string{
+			"expected identifier after let, found '='",
+			"expected expression, found ')'",
+		}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := New(token.NewTokenizer(tc.given))
+			p.Parse()
+			assert.Equal(t, tc.expected, p.Errors())
+		})
+	}
+}
+
+func Test_Diagnostics_Unclosed(t *testing.T) {
+	p := New(token.NewTokenizer("(1 + 2;"))
+	p.Parse()
+
+	require.Len(t, p.Diagnostics(), 1)
+	d := p.Diagnostics()[0]
+
+	assert.Equal(t, "expected ')' to close '(' opened here", d.Hint)
+	assert.Equal(t, []diagnostics.Label{
+		{Span: token.Span{Start: 6, End: 7}, Message: "expected ')'", Primary: true},
+		{Span: token.Span{Start: 0, End: 1}, Message: "unclosed '('"},
+	}, d.Labels)
+}
+
+func Test_RoundTrip(t *testing.T) {
+	testcases := []struct {
+		name  string
+		given string
+	}{
+		{name: "if", given: "if (x) { 1 } else { 2 }"},
+		{name: "if with an operator", given: "if (!x) { 1 }; if (x < 1) { 2 }"},
+		{name: "else if", given: "if (x) { 1 } else if (y) { 2 } else { 3 }"},
+		{name: "while", given: "let x = 0; while (x < 10) { x += 1; if (x == 5) { break; } }"},
+		{name: "while with a literal", given: "while (true) { continue; }"},
+		{name: "for", given: "let n = 0; for (i in 0..10) { n += i; n }"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := parse(t, tc.given)
+
+			var out bytes.Buffer
+			ast.NewWriter(&out).Write(expected)
+
+			actual := parse(t, out.String())
+			assert.Empty(t, ast.Diff(expected, actual, ast.IgnoreSpans()))
+		})
+	}
 }
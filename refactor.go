@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/maybe-joe/monkey/refactor"
+)
+
+func refactorSource(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing refactoring, the refactorings are rename")
+	}
+
+	switch refactoring, args := args[0], args[1:]; refactoring {
+	case "rename":
+		return renameSymbol(args)
+	default:
+		return fmt.Errorf("unknown refactoring %q", refactoring)
+	}
+}
+
+func renameSymbol(args []string) error {
+	flags := flag.NewFlagSet("rename", flag.ContinueOnError)
+	at := flags.String("at", "", "the name to rename, as file:line:column")
+	to := flags.String("to", "", "the new name")
+	diff := flags.Bool("diff", false, "print a unified diff instead of rewriting the file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	name, line, column, err := location(*at)
+	if err != nil {
+		return err
+	}
+
+	src, err := readSource(name)
+	if err != nil {
+		return err
+	}
+
+	result, err := refactor.Rename(src.Code, src.Offset(line, column), *to)
+	if err != nil {
+		return fmt.Errorf("%s:%d:%d: %w", name, line, column, err)
+	}
+
+	if *diff {
+		fmt.Print(refactor.Diff(name, src.Code, result.Code))
+		return nil
+	}
+
+	if result.Code == src.Code {
+		return nil
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, []byte(result.Code), info.Mode().Perm())
+}
+
+// location, splits file:line:column, the file name may itself contain colons.
+func location(at string) (string, int, int, error) {
+	invalid := fmt.Errorf("invalid location %q, expected file:line:column", at)
+
+	rest, col, ok := cut(at)
+	if !ok {
+		return "", 0, 0, invalid
+	}
+	name, ln, ok := cut(rest)
+	if !ok || name == "" {
+		return "", 0, 0, invalid
+	}
+
+	line, err := strconv.Atoi(ln)
+	if err != nil || line < 1 {
+		return "", 0, 0, invalid
+	}
+	column, err := strconv.Atoi(col)
+	if err != nil || column < 1 {
+		return "", 0, 0, invalid
+	}
+
+	return name, line, column, nil
+}
+
+func cut(s string) (string, string, bool) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
@@ -0,0 +1,116 @@
+package refactor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context, the number of unchanged lines shown around each change in a diff.
+const context = 3
+
+// Diff, returns a unified diff from before to after for the file name, or nothing if they
+// are the same. The refactorings here only change code within lines, so lines are compared
+// in place rather than searched for.
+func Diff(name, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	a, b := lines(before), lines(after)
+	if len(a) != len(b) {
+		// Lines added or removed, show the whole file as changed.
+		return header(name) + hunk(a, b, 0, len(a), 0, len(b))
+	}
+
+	var changed []int
+	for i := range a {
+		if a[i] != b[i] {
+			changed = append(changed, i)
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString(header(name))
+	for len(changed) > 0 {
+		// A hunk runs on while the next change is close enough for their context to meet.
+		end := 1
+		for end < len(changed) && changed[end]-changed[end-1] <= 2*context {
+			end++
+		}
+
+		from := max(changed[0]-context, 0)
+		to := min(changed[end-1]+context+1, len(a))
+		out.WriteString(hunk(a, b, from, to, from, to))
+
+		changed = changed[end:]
+	}
+
+	return out.String()
+}
+
+func header(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	return fmt.Sprintf("--- a/%s\n+++ b/%s\n", name, name)
+}
+
+// hunk, returns the lines a[af:at] replaced by b[bf:bt], with the lines the same in both
+// shown as context where they line up.
+func hunk(a, b []string, af, at, bf, bt int) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "@@ -%s +%s @@\n", lineRange(af, at-af), lineRange(bf, bt-bf))
+
+	var removed, added []string
+	flush := func() {
+		for _, l := range removed {
+			out.WriteString(diffLine("-", l))
+		}
+		for _, l := range added {
+			out.WriteString(diffLine("+", l))
+		}
+		removed, added = nil, nil
+	}
+
+	for i, j := af, bf; i < at || j < bt; i, j = i+1, j+1 {
+		switch {
+		case i < at && j < bt && a[i] == b[j] && at-af == bt-bf:
+			flush()
+			out.WriteString(diffLine(" ", a[i]))
+		default:
+			if i < at {
+				removed = append(removed, a[i])
+			}
+			if j < bt {
+				added = append(added, b[j])
+			}
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+func lineRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func diffLine(prefix, line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return prefix + line
+	}
+	return prefix + line + "\n\\ No newline at end of file\n"
+}
+
+// lines, splits code into lines keeping their line endings.
+func lines(code string) []string {
+	l := strings.SplitAfter(code, "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
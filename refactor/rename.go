@@ -0,0 +1,201 @@
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/cst"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/resolver"
+	"github.com/maybe-joe/monkey/token"
+)
+
+// ErrNoName is returned by Rename when there is no name at the offset given.
+var ErrNoName = errors.New("no name to rename")
+
+// Result, the code after a refactoring.
+type Result struct {
+	Code string
+	// Edits, the changes made to the code, in the order written.
+	Edits []parser.Edit
+}
+
+// Rename, renames the let binding or parameter that the identifier at offset binds or refers
+// to, along with every reference to it. Only the identifiers change, the rest of the code is
+// kept as it was written.
+//
+// It refuses when the new name would be captured, a use of the binding ending up referring to
+// another binding of the new name, or capture, another name's use ending up referring to it.
+func Rename(code string, offset int, name string) (Result, error) {
+	if !IsName(name) {
+		return Result{}, fmt.Errorf("%q is not a valid name", name)
+	}
+
+	tree, diags := cst.Parse(code)
+	if len(diags) > 0 {
+		return Result{}, fmt.Errorf("cannot rename in code with syntax errors: %w", diags[0])
+	}
+
+	root := tree.Root.AST.(*ast.RootNode)
+	r := resolver.New()
+	r.Resolve(root)
+
+	ids := identifiers(root)
+	i := slices.IndexFunc(ids, func(id *ast.IdentifierNode) bool {
+		return id.Span.Start <= offset && offset <= id.Span.End
+	})
+	if i < 0 {
+		return Result{}, ErrNoName
+	}
+
+	target := ids[i]
+	s, ok := r.Symbol(target)
+	switch {
+	case !ok:
+		return Result{}, fmt.Errorf("'%s' is not defined", target.Value)
+	case s.Scope == resolver.BuiltinScope:
+		return Result{}, fmt.Errorf("'%s' is a builtin and cannot be renamed", target.Value)
+	case s.Name == name:
+		return Result{Code: code}, nil
+	}
+
+	for _, quoted := range quotedNames(root) {
+		if quoted.Value == s.Name || quoted.Value == name {
+			return Result{}, fmt.Errorf("'%s' is used in quoted code at %s, which renaming cannot follow",
+				quoted.Value, position(code, quoted.Span))
+		}
+	}
+
+	before := definitions(ids, r)
+	binding := before[i]
+	for j, id := range ids {
+		if before[j] == binding {
+			tok, _ := tree.TokenAt(id.Span.Start)
+			tok.Text = name
+		}
+	}
+
+	result := Result{Code: tree.String(), Edits: tree.Edits()}
+	if err := check(code, result.Code, ids, before, binding, s.Name, name); err != nil {
+		return Result{}, err
+	}
+
+	return result, nil
+}
+
+// check, resolves the renamed code, making sure each identifier refers to what it did before.
+func check(code, renamed string, ids []*ast.IdentifierNode, before []int, binding int, old, name string) error {
+	p := parser.New(token.NewTokenizer(renamed))
+	root := p.Parse()
+	if len(p.Diagnostics()) > 0 {
+		return fmt.Errorf("renaming '%s' to '%s' breaks the code: %w", old, name, p.Diagnostics()[0])
+	}
+
+	r := resolver.New()
+	r.Resolve(root)
+	after := definitions(identifiers(root), r)
+
+	for j, id := range ids {
+		if before[j] == after[j] {
+			continue
+		}
+
+		at := position(code, id.Span)
+		if before[j] == binding {
+			return fmt.Errorf("renaming '%s' to '%s' would make the use at %s refer to another '%s'", old, name, at, name)
+		}
+		return fmt.Errorf("renaming '%s' to '%s' would make '%s' at %s refer to the renamed binding", old, name, id.Value, at)
+	}
+
+	return nil
+}
+
+// identifiers, returns every identifier in the tree, in the order written.
+func identifiers(root *ast.RootNode) []*ast.IdentifierNode {
+	var ids []*ast.IdentifierNode
+	ast.Inspect(root, func(n ast.Node) bool {
+		if id, ok := n.(*ast.IdentifierNode); ok {
+			ids = append(ids, id)
+		}
+		return true
+	})
+
+	return ids
+}
+
+const (
+	// undefined, the definition of a name that is not bound, or is in quoted code.
+	undefined = -1
+	// builtin, the definition of a builtin.
+	builtin = -2
+)
+
+// definitions, returns the index in ids of the identifier binding the name each refers to.
+func definitions(ids []*ast.IdentifierNode, r *resolver.Resolver) []int {
+	index := map[token.Span]int{}
+	for i, id := range ids {
+		index[id.Span] = i
+	}
+
+	defs := make([]int, len(ids))
+	for i, id := range ids {
+		s, ok := r.Symbol(id)
+		switch {
+		case !ok:
+			defs[i] = undefined
+		case s.Scope == resolver.BuiltinScope:
+			defs[i] = builtin
+		default:
+			defs[i] = index[s.Span]
+		}
+	}
+
+	return defs
+}
+
+// quotedNames, returns the identifiers in quoted code outside of unquote, which
+// name whatever is in scope where the code ends up.
+func quotedNames(root *ast.RootNode) []*ast.IdentifierNode {
+	var quoted []*ast.IdentifierNode
+	ast.Inspect(root, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallNode)
+		if !ok || !isCall(call, "quote") {
+			return true
+		}
+
+		for _, arg := range call.Arguments {
+			ast.Inspect(arg, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallNode); ok && isCall(call, "unquote") {
+					return false
+				}
+				if id, ok := n.(*ast.IdentifierNode); ok {
+					quoted = append(quoted, id)
+				}
+				return true
+			})
+		}
+		return false
+	})
+
+	return quoted
+}
+
+func isCall(call *ast.CallNode, name string) bool {
+	id, ok := call.Function.(*ast.IdentifierNode)
+	return ok && id.Value == name
+}
+
+// IsName, returns true if name can be used to name a binding.
+func IsName(name string) bool {
+	tokens := token.NewTokenizer(name).Tokenize()
+	return len(tokens) == 2 && tokens[0].Is(token.IDENT) && tokens[0].Literal == name &&
+		!slices.Contains(resolver.Builtins, name)
+}
+
+func position(code string, span token.Span) string {
+	p := diagnostics.NewSource("", code).Position(span.Start)
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
@@ -0,0 +1,214 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Rename(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		at       int
+		to       string
+		expected string
+	}{
+		{
+			name:     "let from its binding",
+			given:    "let a = 1; // the a\nlet b = a + 1;\nb * a",
+			at:       4,
+			to:       "count",
+			expected: "let count = 1; // the a\nlet b = count + 1;\nb * count",
+		},
+		{
+			name:     "let from a use",
+			given:    "let a = 1;\na",
+			at:       11,
+			to:       "b",
+			expected: "let b = 1;\nb",
+		},
+		{
+			name:     "parameter",
+			given:    "let f = fn(x, y) {  x + y };\nlet x = 2;\nf(x, 1)",
+			at:       11,
+			to:       "n",
+			expected: "let f = fn(n, y) {  n + y };\nlet x = 2;\nf(x, 1)",
+		},
+		{
+			name:     "shadowed",
+			given:    "let a = 1;\nlet f = fn(a) { a };\nlet g = fn() { let a = 2; a };\na",
+			at:       4,
+			to:       "z",
+			expected: "let z = 1;\nlet f = fn(a) { a };\nlet g = fn() { let a = 2; a };\nz",
+		},
+		{
+			name:     "inner binding",
+			given:    "let a = 1;\nlet g = fn() { let a = 2; a };\na",
+			at:       30,
+			to:       "z",
+			expected: "let a = 1;\nlet g = fn() { let z = 2; z };\na",
+		},
+		{
+			name:     "recursive function",
+			given:    "let f = fn(n) { if (n < 1) { 0 } else { f(n - 1) } };\nf(3)",
+			at:       4,
+			to:       "count",
+			expected: "let count = fn(n) { if (n < 1) { 0 } else { count(n - 1) } };\ncount(3)",
+		},
+		{
+			name:     "assigned",
+			given:    "let a = 1;\nlet f = fn() { a += 1 };\na = 3",
+			at:       4,
+			to:       "n",
+			expected: "let n = 1;\nlet f = fn() { n += 1 };\nn = 3",
+		},
+		{
+			name:     "same name",
+			given:    "let a = 1;\na",
+			at:       4,
+			to:       "a",
+			expected: "let a = 1;\na",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Rename(tc.given, tc.at, tc.to)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result.Code)
+		})
+	}
+}
+
+func Test_Rename_Edits(t *testing.T) {
+	result, err := Rename("let a = 1;\na + a", 4, "bb")
+	require.NoError(t, err)
+
+	assert.Equal(t, []parser.Edit{
+		{Span: token.Span{Start: 4, End: 5}, Text: "bb"},
+		{Span: token.Span{Start: 11, End: 12}, Text: "bb"},
+		{Span: token.Span{Start: 15, End: 16}, Text: "bb"},
+	}, result.Edits)
+}
+
+func Test_Rename_Refused(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		at       int
+		to       string
+		expected string
+	}{
+		{
+			name:     "captured by an inner binding",
+			given:    "let a = 1;\nlet f = fn(b) { a + b };\nf(2)",
+			at:       4,
+			to:       "b",
+			expected: "renaming 'a' to 'b' would make the use at 2:17 refer to another 'b'",
+		},
+		{
+			name:     "capturing an outer binding",
+			given:    "let a = 1;\nlet f = fn(b) { a + b };\nf(2)",
+			at:       22,
+			to:       "a",
+			expected: "renaming 'b' to 'a' would make 'a' at 2:17 refer to the renamed binding",
+		},
+		{
+			name:     "shadowing a later global",
+			given:    "let f = fn(x) { y };\nlet y = 1;\nf(y)",
+			at:       11,
+			to:       "y",
+			expected: "renaming 'x' to 'y' would make 'y' at 1:17 refer to the renamed binding",
+		},
+		{
+			name:     "quoted",
+			given:    "let a = 1;\nquote(a + 1)",
+			at:       4,
+			to:       "b",
+			expected: "'a' is used in quoted code at 2:7, which renaming cannot follow",
+		},
+		{
+			name:     "keyword",
+			given:    "let a = 1;",
+			at:       4,
+			to:       "let",
+			expected: `"let" is not a valid name`,
+		},
+		{
+			name:     "not a name",
+			given:    "let a = 1;",
+			at:       4,
+			to:       "a b",
+			expected: `"a b" is not a valid name`,
+		},
+		{
+			name:     "builtin name",
+			given:    "let a = 1;",
+			at:       4,
+			to:       "quote",
+			expected: `"quote" is not a valid name`,
+		},
+		{
+			name:     "builtin",
+			given:    "quote(1)",
+			at:       0,
+			to:       "q",
+			expected: "'quote' is a builtin and cannot be renamed",
+		},
+		{
+			name:     "undefined",
+			given:    "a",
+			at:       0,
+			to:       "b",
+			expected: "'a' is not defined",
+		},
+		{
+			name:     "no name",
+			given:    "let a = 1;",
+			at:       8,
+			to:       "b",
+			expected: "no name to rename",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Rename(tc.given, tc.at, tc.to)
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func Test_Rename_SyntaxErrors(t *testing.T) {
+	_, err := Rename("let a = ;", 4, "b")
+	assert.ErrorContains(t, err, "cannot rename in code with syntax errors")
+}
+
+func Test_Diff(t *testing.T) {
+	before := "let a = 1;\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\na\n"
+	after := strings.ReplaceAll(before, "a", "b")
+
+	assert.Equal(t, `--- a/main.mk
++++ b/main.mk
+@@ -1,4 +1,4 @@
+-let a = 1;
++let b = 1;
+ 2
+ 3
+ 4
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-a
++b
+`, Diff("main.mk", before, after))
+
+	assert.Equal(t, "--- a/main.mk\n+++ b/main.mk\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file\n", Diff("main.mk", "a", "b"))
+	assert.Empty(t, Diff("main.mk", "a", "a"))
+}
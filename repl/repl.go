@@ -2,28 +2,188 @@ package repl
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"strings"
 
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/evaluator"
+	"github.com/maybe-joe/monkey/highlight"
+	"github.com/maybe-joe/monkey/object"
+	"github.com/maybe-joe/monkey/parser"
 	"github.com/maybe-joe/monkey/token"
 )
 
-const prompt = ">> "
+const DefaultPrompt = ">> "
 
-// let add = fn(x, y) { x + y; };
-func Run(in io.Reader, out io.Writer) error {
-	scanner := bufio.NewScanner(in)
+// REPL, reads lines of monkey code from In, evaluates them and writes the results to Out.
+// Bindings made by one line are visible to the lines after it.
+//
+// A line starting with ":ast " is parsed and the resulting tree is written
+// back as source, a line starting with ":tokens " is written out one token per line.
+type REPL struct {
+	// Prompt, written to Out before each line is read.
+	Prompt string
+	// Banner, written to Out once before the first prompt.
+	Banner string
+	// In, the source of the lines to process.
+	In io.Reader
+	// Out, where prompts and results are written.
+	Out io.Writer
+	// Err, where errors are written.
+	Err io.Writer
+	// Formatter, used to highlight the results written to Out.
+	Formatter highlight.Formatter
+
+	env       *object.Environment
+	macros    *object.Environment
+	evaluator *evaluator.Evaluator
+	// source, every line read so far, so errors can point back into earlier lines.
+	source *diagnostics.Source
+}
+
+// New creates a REPL reading from in and writing both results and errors to out.
+func New(in io.Reader, out io.Writer) *REPL {
+	return &REPL{
+		Prompt:    DefaultPrompt,
+		In:        in,
+		Out:       out,
+		Err:       out,
+		Formatter: highlight.Text{},
+		env:       object.NewEnvironment(),
+		macros:    object.NewEnvironment(),
+		evaluator: evaluator.New(),
+		source:    diagnostics.NewSource("repl", ""),
+	}
+}
+
+// Run reads lines until In is exhausted, returning any error from reading In.
+func (r *REPL) Run() error {
+	scanner := bufio.NewScanner(r.In)
+
+	if len(r.Banner) > 0 {
+		fmt.Fprint(r.Out, r.Banner)
+	}
 
 	for {
-		fmt.Print(prompt)
+		fmt.Fprint(r.Out, r.Prompt)
 		if !scanner.Scan() {
 			break
 		}
 
-		for _, t := range token.NewTokenizer(scanner.Text()).Tokenize() {
-			fmt.Fprintf(out, "%s\n", t)
-		}
+		r.Line(scanner.Text())
+	}
+
+	return scanner.Err()
+}
+
+// Line, processes a single line of input.
+func (r *REPL) Line(line string) {
+	offset := len(r.source.Code)
+	r.source.Append(line + "\n")
+
+	if code, ok := strings.CutPrefix(line, ":ast "); ok {
+		r.Ast(code, offset+len(":ast "))
+		return
+	}
+
+	if code, ok := strings.CutPrefix(line, ":tokens "); ok {
+		r.Tokens(code)
+		return
+	}
+
+	r.Eval(line, offset)
+}
+
+// Eval, evaluates code and writes the result.
+func (r *REPL) Eval(code string, offset int) {
+	root, ok := r.Parse(code, offset)
+	if !ok {
+		return
+	}
+
+	evaluator.DefineMacros(root, r.macros)
+	expanded, err := evaluator.ExpandMacros(root, r.macros)
+	if err != nil {
+		r.Diagnostics(err.Diagnostic(r.Source()))
+		return
+	}
+
+	result := r.evaluator.Eval(expanded, r.env)
+
+	switch result := result.(type) {
+	case nil:
+	case *object.Error:
+		r.Diagnostics(result.Diagnostic(r.Source()))
+	default:
+		highlight.Source(r.Out, result.Inspect(), r.Formatter)
+		fmt.Fprint(r.Out, "\n")
+	}
+}
+
+// Tokens, writes out each token of code on its own line.
+func (r *REPL) Tokens(code string) {
+	for _, t := range token.NewTokenizer(code).Tokenize() {
+		r.Formatter.Format(r.Out, highlight.Classify(t.Type), t.String())
+		fmt.Fprint(r.Out, "\n")
+	}
+}
+
+// Ast, parses code and writes the tree back out as highlighted source.
+func (r *REPL) Ast(code string, offset int) {
+	root, ok := r.Parse(code, offset)
+	if !ok {
+		return
 	}
 
-	return nil
+	var buf bytes.Buffer
+	ast.NewWriter(&buf).Write(root)
+
+	highlight.Source(r.Out, buf.String(), r.Formatter)
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		fmt.Fprint(r.Out, "\n")
+	}
+}
+
+// Parse, parses code found at offset in the history, reporting any problems to Err.
+func (r *REPL) Parse(code string, offset int) (*ast.RootNode, bool) {
+	p := parser.New(&shifted{Tokenizer: token.NewTokenizer(code), offset: offset})
+	root := p.Parse()
+
+	if diags := p.Diagnostics(); len(diags) > 0 {
+		r.Diagnostics(diags...)
+		return nil, false
+	}
+
+	return root, true
+}
+
+// Source, every line read so far.
+func (r *REPL) Source() *diagnostics.Source {
+	return r.source
+}
+
+// Diagnostics, renders problems to Err.
+func (r *REPL) Diagnostics(diags ...diagnostics.Diagnostic) {
+	renderer := diagnostics.NewRenderer(r.Err, r.Source())
+	renderer.Formatter = r.Formatter
+	renderer.Render(diags...)
+}
+
+// Run is shorthand for New(in, out).Run().
+func Run(in io.Reader, out io.Writer) error {
+	return New(in, out).Run()
+}
+
+// shifted, reports spans relative to the whole history rather than the current line.
+type shifted struct {
+	*token.Tokenizer
+	offset int
+}
+
+func (s *shifted) Span() token.Span {
+	span := s.Tokenizer.Span()
+	return token.Span{Start: span.Start + s.offset, End: span.End + s.offset}
 }
@@ -1,15 +1,17 @@
 package repl
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/maybe-joe/monkey/highlight"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_Repl(t *testing.T) {
 	var (
-		text = "let add = fn(x, y) { x + y; };"
+		text = ":tokens let add = fn(x, y) { x + y; };"
 		in   = strings.NewReader(text)
 		out  strings.Builder
 	)
@@ -17,7 +19,7 @@ func Test_Repl(t *testing.T) {
 	err := Run(in, &out)
 	require.NoError(t, err)
 
-	exptected := `LET
+	exptected := `>> LET
 IDENT add
 =
 FUNCTION
@@ -34,7 +36,120 @@ IDENT y
 }
 ;
 EOF
-`
+>> `
 
 	require.Equal(t, exptected, out.String())
 }
+
+func Test_Repl_Configured(t *testing.T) {
+	var (
+		in  = strings.NewReader("1\n2")
+		out strings.Builder
+	)
+
+	r := New(in, &out)
+	r.Prompt = "$ "
+	r.Banner = "hello\n"
+
+	require.NoError(t, r.Run())
+	require.Equal(t, "hello\n$ 1\n$ 2\n$ ", out.String())
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("boom")
+}
+
+func Test_Repl_ScannerError(t *testing.T) {
+	var out strings.Builder
+
+	err := New(failingReader{}, &out).Run()
+	require.EqualError(t, err, "boom")
+}
+
+func Test_Repl_Ast(t *testing.T) {
+	var (
+		in  = strings.NewReader(":ast let x = 1 + 2 * 3;\n:ast -a * b")
+		out strings.Builder
+	)
+
+	require.NoError(t, Run(in, &out))
+	require.Equal(t, ">> let x = (1 + (2 * 3));\n>> (-a * b)\n>> ", out.String())
+}
+
+func Test_Repl_Highlight(t *testing.T) {
+	var (
+		in  = strings.NewReader(":ast let x = 1;")
+		out strings.Builder
+	)
+
+	r := New(in, &out)
+	r.Formatter = highlight.ANSI{}
+
+	require.NoError(t, r.Run())
+	require.Equal(t, ">> \x1b[35mlet\x1b[0m \x1b[36mx\x1b[0m \x1b[34m=\x1b[0m \x1b[33m1\x1b[0m;\n>> ", out.String())
+}
+
+func Test_Repl_Ast_Diagnostics(t *testing.T) {
+	var (
+		in  = strings.NewReader(":ast add(1, 2;")
+		out strings.Builder
+	)
+
+	require.NoError(t, Run(in, &out))
+
+	expected := `>> error: expected ')', found ';'
+ --> repl:1:14
+  |
+1 | :ast add(1, 2;
+  |              ^ expected ')'
+  |         - unclosed '('
+  = hint: expected ')' to close '(' opened here
+>> `
+	require.Equal(t, expected, out.String())
+}
+
+func Test_Repl_Eval(t *testing.T) {
+	var (
+		in  = strings.NewReader("let add = fn(x, y) { x + y };\nadd(1, 2)\nadd")
+		out strings.Builder
+	)
+
+	require.NoError(t, Run(in, &out))
+	require.Equal(t, ">> >> 3\n>> fn(x, y) {\n\t(x + y)\n}\n>> ", out.String())
+}
+
+func Test_Repl_StackTrace(t *testing.T) {
+	var (
+		in  = strings.NewReader("let check = fn(x) { x + true };\nlet run = fn() { check(1) };\nrun()")
+		out strings.Builder
+		err strings.Builder
+	)
+
+	r := New(in, &out)
+	r.Err = &err
+
+	require.NoError(t, r.Run())
+	require.Equal(t, ">> >> >> >> ", out.String())
+
+	expected := `error: type mismatch: INTEGER + BOOLEAN
+ --> repl:1:21
+  |
+1 | let check = fn(x) { x + true };
+  |                     ^^^^^^^^
+  = note: in check, called at repl:2:18
+  = note: in run, called at repl:3:1
+`
+	require.Equal(t, expected, err.String())
+}
+
+func Test_Repl_Macros(t *testing.T) {
+	var (
+		in  = strings.NewReader("let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) };\nunless(1 > 2, 10, 20)")
+		out strings.Builder
+	)
+
+	require.NoError(t, Run(in, &out))
+	require.Equal(t, ">> >> 10\n>> ", out.String())
+}
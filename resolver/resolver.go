@@ -0,0 +1,318 @@
+package resolver
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+)
+
+// binding, a name bound by a let or a parameter.
+type binding struct {
+	symbol *Symbol
+	kind   string
+}
+
+// Resolver, works out which binding each identifier in a program refers to,
+// reporting the names that are not bound and bindings that are never used.
+//
+// The bodies of functions are resolved after the rest of the scope around them,
+// since they run when called, by which point the lets following them have run.
+// This lets functions refer to themselves and to each other.
+type Resolver struct {
+	table       *SymbolTable
+	symbols     map[*ast.IdentifierNode]*Symbol
+	tables      map[ast.Node]*SymbolTable
+	bindings    []binding
+	used        map[*Symbol]bool
+	deferred    []func()
+	diagnostics []diagnostics.Diagnostic
+}
+
+func New() *Resolver {
+	return &Resolver{
+		table:   NewSymbolTable(),
+		symbols: map[*ast.IdentifierNode]*Symbol{},
+		tables:  map[ast.Node]*SymbolTable{},
+		used:    map[*Symbol]bool{},
+	}
+}
+
+// Resolve, resolves the identifiers in root. Resolving several programs with the same
+// resolver runs them in the same global scope, as the lines typed into a repl.
+func (r *Resolver) Resolve(root *ast.RootNode) {
+	r.bindings = nil
+
+	for _, stmt := range root.Statements {
+		r.Node(stmt)
+	}
+	r.Flush()
+
+	for _, b := range r.bindings {
+		if r.used[b.symbol] || strings.HasPrefix(b.symbol.Name, "_") {
+			continue
+		}
+
+		r.diagnostics = append(r.diagnostics,
+			diagnostics.Warningf(b.symbol.Span, "unused %s '%s'", b.kind, b.symbol.Name).
+				WithMessage("never used").
+				WithHint("start the name with an underscore if this is intended"),
+		)
+	}
+
+	sort.SliceStable(r.diagnostics, func(i, j int) bool {
+		a, _ := r.diagnostics[i].Primary()
+		b, _ := r.diagnostics[j].Primary()
+		return a.Start < b.Start
+	})
+}
+
+func (r *Resolver) Node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.BlockNode:
+		if n == nil {
+			return
+		}
+		// Blocks share the scope of the function around them.
+		for _, stmt := range n.Statements {
+			r.Node(stmt)
+		}
+	case *ast.LetNode:
+		r.Node(n.Value)
+		r.Define(n.Identifier, "variable")
+	case *ast.ReturnNode:
+		r.Node(n.Value)
+	case *ast.ExpressionStatementNode:
+		r.Node(n.Expression)
+	case *ast.IfNode:
+		r.Node(n.Condition)
+		r.Node(n.Consequence)
+		r.Node(n.Alternative)
+	case *ast.ConditionalNode:
+		r.Node(n.Condition)
+		r.Node(n.Consequence)
+		r.Node(n.Alternative)
+	case *ast.WhileNode:
+		first := len(r.bindings)
+		r.Node(n.Condition)
+		r.Node(n.Body)
+		r.Loop(n, r.bindings[first:])
+	case *ast.ForNode:
+		r.For(n)
+	case *ast.FunctionNode:
+		r.Defer(n, n.Parameters, n.Body)
+	case *ast.MacroNode:
+		r.Defer(n, n.Parameters, n.Body)
+	case *ast.CallNode:
+		r.Node(n.Function)
+		if id, ok := n.Function.(*ast.IdentifierNode); ok && id.Value == "quote" {
+			r.Quoted(n.Arguments)
+			return
+		}
+		for _, arg := range n.Arguments {
+			r.Node(arg)
+		}
+	case *ast.PrefixNode:
+		r.Node(n.Right)
+	case *ast.InfixNode:
+		r.Node(n.Left)
+		r.Node(n.Right)
+	case *ast.AssignNode:
+		r.Node(n.Value)
+		r.Assign(n)
+	case *ast.RangeNode:
+		r.Node(n.Start)
+		r.Node(n.End)
+	case *ast.IdentifierNode:
+		r.Use(n)
+	}
+}
+
+// For, resolves a for loop. The body has a scope of its own holding the loop variable,
+// since each time round it runs in a new environment.
+func (r *Resolver) For(n *ast.ForNode) {
+	r.Node(n.Iterable)
+
+	saved := r.table
+	r.table = NewBlockSymbolTable(saved)
+	defer func() { r.table = saved }()
+
+	r.tables[n] = r.table
+	r.Define(n.Value, "loop variable")
+	r.Node(n.Body)
+}
+
+// Loop, marks the lets in a loop that bind a name the loop reads as used. The loop runs
+// again after them, when the names read refer to what they bound.
+func (r *Resolver) Loop(n *ast.WhileNode, bindings []binding) {
+	read, assigned := map[string]bool{}, map[*ast.IdentifierNode]bool{}
+	ast.Inspect(n, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignNode:
+			assigned[node.Name] = node.Operator == "="
+		case *ast.IdentifierNode:
+			if s, ok := r.symbols[node]; ok && s.Span != node.Span && !assigned[node] {
+				read[node.Value] = true
+			}
+		}
+		return true
+	})
+
+	for _, b := range bindings {
+		if read[b.symbol.Name] {
+			r.used[b.symbol] = true
+		}
+	}
+}
+
+// Defer, resolves the parameters and body of a function once the scope around it is done.
+func (r *Resolver) Defer(node ast.Node, params []*ast.IdentifierNode, body *ast.BlockNode) {
+	outer := r.table
+
+	r.deferred = append(r.deferred, func() {
+		saved, deferred := r.table, r.deferred
+		r.table, r.deferred = NewEnclosedSymbolTable(outer), nil
+		defer func() { r.table, r.deferred = saved, deferred }()
+
+		r.tables[node] = r.table
+
+		seen := map[string]*ast.IdentifierNode{}
+		for _, param := range params {
+			if first, ok := seen[param.Value]; ok {
+				r.diagnostics = append(r.diagnostics,
+					diagnostics.Errorf(param.Span, "duplicate parameter '%s'", param.Value).
+						WithMessage("used again here").
+						WithLabel(first.Span, "first used here"),
+				)
+				// The first can never be used, which the error already says.
+				r.used[r.symbols[first]] = true
+			}
+			seen[param.Value] = param
+
+			r.Define(param, "parameter")
+		}
+
+		if body != nil {
+			r.Node(body)
+		}
+		r.Flush()
+	})
+}
+
+// Flush, resolves the functions deferred so far.
+func (r *Resolver) Flush() {
+	for len(r.deferred) > 0 {
+		next := r.deferred[0]
+		r.deferred = r.deferred[1:]
+		next()
+	}
+}
+
+// Quoted, resolves the unquoted parts of the arguments to quote, the rest is data.
+func (r *Resolver) Quoted(args []ast.Expression) {
+	for _, arg := range args {
+		ast.Inspect(arg, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallNode)
+			if !ok {
+				return true
+			}
+
+			if id, ok := call.Function.(*ast.IdentifierNode); ok && id.Value == "unquote" {
+				r.Node(call)
+				return false
+			}
+
+			return true
+		})
+	}
+}
+
+// Define, binds the name of id in the current scope.
+func (r *Resolver) Define(id *ast.IdentifierNode, kind string) {
+	if id == nil {
+		return
+	}
+
+	s := r.table.Define(id.Value, id.Span)
+	r.symbols[id] = s
+	r.bindings = append(r.bindings, binding{symbol: s, kind: kind})
+}
+
+// Use, resolves id, reporting it if the name is not bound.
+func (r *Resolver) Use(id *ast.IdentifierNode) {
+	s, ok := r.table.resolve(id.Value, func(s *Symbol) { r.used[s] = true })
+	if !ok {
+		r.diagnostics = append(r.diagnostics,
+			diagnostics.Errorf(id.Span, "undefined name '%s'", id.Value).WithMessage("not found in this scope"),
+		)
+		return
+	}
+
+	r.symbols[id] = s
+}
+
+// Assign, resolves the name an assignment rebinds, reporting it if the name is not bound
+// or is a builtin. Only a compound assignment reads the name, so a binding that is only
+// ever assigned to is still unused.
+func (r *Resolver) Assign(n *ast.AssignNode) {
+	visit := func(*Symbol) {}
+	if n.Operator != "=" {
+		visit = func(s *Symbol) { r.used[s] = true }
+	}
+
+	s, ok := r.table.resolve(n.Name.Value, visit)
+	switch {
+	case !ok:
+		r.diagnostics = append(r.diagnostics,
+			diagnostics.Errorf(n.Name.Span, "cannot assign to undefined name '%s'", n.Name.Value).
+				WithMessage("not found in this scope").
+				WithHint("use let to bind a new name"),
+		)
+	case s.Scope == BuiltinScope:
+		r.diagnostics = append(r.diagnostics,
+			diagnostics.Errorf(n.Name.Span, "cannot assign to builtin '%s'", n.Name.Value).
+				WithMessage("provided by the language"),
+		)
+	default:
+		r.symbols[n.Name] = s
+	}
+}
+
+// Symbol, returns the symbol id refers to or, for the identifier in a let or a parameter, binds.
+func (r *Resolver) Symbol(id *ast.IdentifierNode) (*Symbol, bool) {
+	s, ok := r.symbols[id]
+	return s, ok
+}
+
+// Used, returns true if the binding s is referred to anywhere.
+func (r *Resolver) Used(s *Symbol) bool {
+	return r.used[s]
+}
+
+// Table, returns the symbol table of a function, macro or for loop.
+func (r *Resolver) Table(node ast.Node) (*SymbolTable, bool) {
+	t, ok := r.tables[node]
+	return t, ok
+}
+
+// Globals, returns the symbol table for the top level of the program.
+func (r *Resolver) Globals() *SymbolTable {
+	return r.table
+}
+
+func (r *Resolver) Diagnostics() []diagnostics.Diagnostic {
+	return r.diagnostics
+}
+
+// Errors, returns only the diagnostics that are errors.
+func (r *Resolver) Errors() []diagnostics.Diagnostic {
+	var errors []diagnostics.Diagnostic
+	for _, d := range r.diagnostics {
+		if d.Severity == diagnostics.Error {
+			errors = append(errors, d)
+		}
+	}
+
+	return errors
+}
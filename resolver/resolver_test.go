@@ -0,0 +1,226 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resolve(t *testing.T, code string) (*Resolver, *ast.RootNode) {
+	t.Helper()
+
+	p := parser.New(token.NewTokenizer(code))
+	root := p.Parse()
+	require.Empty(t, p.Errors())
+
+	r := New()
+	r.Resolve(root)
+
+	return r, root
+}
+
+// symbols, describes the symbol of each identifier in the order they were written.
+func symbols(r *Resolver, root *ast.RootNode) []string {
+	var result []string
+
+	ast.Inspect(root, func(n ast.Node) bool {
+		if id, ok := n.(*ast.IdentifierNode); ok {
+			if s, ok := r.Symbol(id); ok {
+				result = append(result, s.Name+" "+string(s.Scope)+" "+string(rune('0'+s.Index)))
+			} else {
+				result = append(result, id.Value+" ?")
+			}
+		}
+
+		return true
+	})
+
+	return result
+}
+
+func messages(r *Resolver) []string {
+	var result []string
+	for _, d := range r.Diagnostics() {
+		result = append(result, string(d.Severity)+": "+d.Message)
+	}
+
+	return result
+}
+
+func Test_Resolve_Scopes(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected []string
+	}{
+		{
+			name:     "globals",
+			given:    "let a = 1; let b = a; b",
+			expected: []string{"a GLOBAL 0", "b GLOBAL 1", "a GLOBAL 0", "b GLOBAL 1"},
+		},
+		{
+			name:     "locals",
+			given:    "let f = fn(a, b) { let c = a; c + b }; f(1, 2)",
+			expected: []string{"f GLOBAL 0", "a LOCAL 0", "b LOCAL 1", "c LOCAL 2", "a LOCAL 0", "c LOCAL 2", "b LOCAL 1", "f GLOBAL 0"},
+		},
+		{
+			name:     "free",
+			given:    "let f = fn(a, b) { fn() { b + a } }; f(1, 2)",
+			expected: []string{"f GLOBAL 0", "a LOCAL 0", "b LOCAL 1", "b FREE 0", "a FREE 1", "f GLOBAL 0"},
+		},
+		{
+			name:     "free through several functions",
+			given:    "let f = fn(a) { fn() { fn() { a } } }; f(1)",
+			expected: []string{"f GLOBAL 0", "a LOCAL 0", "a FREE 0", "f GLOBAL 0"},
+		},
+		{
+			name:     "rebinding reuses the slot",
+			given:    "let a = 1; let b = a; let a = b; a",
+			expected: []string{"a GLOBAL 0", "b GLOBAL 1", "a GLOBAL 0", "a GLOBAL 0", "b GLOBAL 1", "a GLOBAL 0"},
+		},
+		{
+			name:     "blocks share the function scope",
+			given:    "let f = fn(a) { if (a) { let b = 1; } b }; f(1)",
+			expected: []string{"f GLOBAL 0", "a LOCAL 0", "a LOCAL 0", "b LOCAL 1", "b LOCAL 1", "f GLOBAL 0"},
+		},
+		{
+			name:     "loop variable",
+			given:    "let n = 3; for (x in 0..n) { let y = x; x + y }",
+			expected: []string{"n GLOBAL 0", "x LOCAL 0", "n GLOBAL 0", "y LOCAL 1", "x LOCAL 0", "x LOCAL 0", "y LOCAL 1"},
+		},
+		{
+			name:     "loops do not capture",
+			given:    "let f = fn(a) { for (i in 0..a) { fn() { a + i } } }; f(1)",
+			expected: []string{"f GLOBAL 0", "a LOCAL 0", "i LOCAL 0", "a LOCAL 0", "a FREE 0", "i FREE 1", "f GLOBAL 0"},
+		},
+		{
+			name:     "assignment",
+			given:    "let a = 1; let f = fn(b) { a += b; b = 2 }; f(1)",
+			expected: []string{"a GLOBAL 0", "f GLOBAL 1", "b LOCAL 0", "a GLOBAL 0", "b LOCAL 0", "b LOCAL 0", "f GLOBAL 1"},
+		},
+		{
+			name:     "assignment captures",
+			given:    "let f = fn() { let n = 0; fn() { n = n + 1 } }; f()",
+			expected: []string{"f GLOBAL 0", "n LOCAL 0", "n FREE 0", "n FREE 0", "f GLOBAL 0"},
+		},
+		{
+			name:     "recursion",
+			given:    "let f = fn(n) { f(n) }; f(1)",
+			expected: []string{"f GLOBAL 0", "n LOCAL 0", "f GLOBAL 0", "n LOCAL 0", "f GLOBAL 0"},
+		},
+		{
+			name:     "functions see later lets",
+			given:    "let f = fn() { g() }; let g = fn() { f() }; f()",
+			expected: []string{"f GLOBAL 0", "g GLOBAL 1", "g GLOBAL 1", "f GLOBAL 0", "f GLOBAL 0"},
+		},
+		{
+			name:     "quoted code is not resolved",
+			given:    "let a = 1; quote(b + unquote(a))",
+			expected: []string{"a GLOBAL 0", "quote BUILTIN 0", "b ?", "unquote BUILTIN 1", "a GLOBAL 0"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, root := resolve(t, tc.given)
+			assert.Empty(t, r.Diagnostics())
+			assert.Equal(t, tc.expected, symbols(r, root))
+		})
+	}
+}
+
+func Test_Resolve_Diagnostics(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected []string
+	}{
+		{name: "undefined", given: "a + 1", expected: []string{"error: undefined name 'a'"}},
+		{name: "used before let", given: "let b = a; let a = 1; b + a", expected: []string{"error: undefined name 'a'"}},
+		{name: "let is not recursive", given: "let a = a; a", expected: []string{"error: undefined name 'a'"}},
+		{name: "parameter out of scope", given: "let f = fn(a) { a }; f(a)", expected: []string{"error: undefined name 'a'"}},
+		{name: "duplicate parameter", given: "let f = fn(a, a) { a }; f(1, 2)", expected: []string{"error: duplicate parameter 'a'"}},
+		{name: "unused variable", given: "let a = 1;", expected: []string{"warning: unused variable 'a'"}},
+		{name: "unused parameter", given: "let f = fn(a, b) { a }; f(1, 2)", expected: []string{"warning: unused parameter 'b'"}},
+		{name: "overwritten before use", given: "let a = 1; let a = 2; a", expected: []string{"warning: unused variable 'a'"}},
+		{name: "rebound in loop", given: "let i = 0; while (i < 10) { let i = i + 1; }", expected: nil},
+		{name: "unused in loop", given: "while (true) { let a = 1; break; }", expected: []string{"warning: unused variable 'a'"}},
+		{name: "undefined in loop", given: "while (a) { continue; }", expected: []string{"error: undefined name 'a'"}},
+		{name: "loop variable out of scope", given: "for (i in 0..3) { i }; i", expected: []string{"error: undefined name 'i'"}},
+		{name: "unused loop variable", given: "for (i in 0..3) { 1 }", expected: []string{"warning: unused loop variable 'i'"}},
+		{name: "assign undefined", given: "a = 1", expected: []string{"error: cannot assign to undefined name 'a'"}},
+		{name: "assign builtin", given: "quote = 1", expected: []string{"error: cannot assign to builtin 'quote'"}},
+		{name: "only assigned", given: "let a = 1; a = 2;", expected: []string{"warning: unused variable 'a'"}},
+		{name: "compound assignment reads", given: "let a = 1; a += 2;", expected: nil},
+		{name: "assigned in loop", given: "let i = 0; while (true) { let i = 1; i = 2; }", expected: []string{"warning: unused variable 'i'", "warning: unused variable 'i'"}},
+		{name: "undefined in conditional", given: "let a = 1; a ? b : c", expected: []string{"error: undefined name 'b'", "error: undefined name 'c'"}},
+		{name: "undefined in else if", given: "if (false) { 1 } else if (a) { 2 }", expected: []string{"error: undefined name 'a'"}},
+		{name: "underscore", given: "let _a = 1; let f = fn(_b) { 1 }; f(1)", expected: nil},
+		{
+			name:     "sorted by location",
+			given:    "let f = fn(x) { y }; let z = 1; f(1)",
+			expected: []string{"warning: unused parameter 'x'", "error: undefined name 'y'", "warning: unused variable 'z'"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := resolve(t, tc.given)
+			assert.Equal(t, tc.expected, messages(r))
+		})
+	}
+}
+
+func Test_Resolve_Labels(t *testing.T) {
+	r, _ := resolve(t, "let f = fn(a, a) { a }; f(1, 2)")
+	require.Len(t, r.Errors(), 1)
+
+	d := r.Errors()[0]
+	require.Len(t, d.Labels, 2)
+	assert.Equal(t, token.Span{Start: 14, End: 15}, d.Labels[0].Span)
+	assert.Equal(t, token.Span{Start: 11, End: 12}, d.Labels[1].Span)
+	assert.Equal(t, "first used here", d.Labels[1].Message)
+}
+
+func Test_Resolve_Tables(t *testing.T) {
+	r, root := resolve(t, "let f = fn(a) { let b = 1; fn() { a + b } }; f(1)")
+
+	var functions []*ast.FunctionNode
+	ast.Inspect(root, func(n ast.Node) bool {
+		if fn, ok := n.(*ast.FunctionNode); ok {
+			functions = append(functions, fn)
+		}
+		return true
+	})
+	require.Len(t, functions, 2)
+
+	outer, ok := r.Table(functions[0])
+	require.True(t, ok)
+	assert.Equal(t, 2, outer.Slots())
+
+	inner, ok := r.Table(functions[1])
+	require.True(t, ok)
+	assert.Equal(t, 0, inner.Slots())
+	require.Len(t, inner.Free, 2)
+	assert.Equal(t, "a", inner.Free[0].Name)
+	assert.Equal(t, LocalScope, inner.Free[0].Scope)
+
+	assert.Equal(t, 1, r.Globals().Slots())
+}
+
+func Test_Resolve_Repl(t *testing.T) {
+	r := New()
+
+	for _, line := range []string{"let a = 1;", "let b = a + 1;", "b"} {
+		p := parser.New(token.NewTokenizer(line))
+		root := p.Parse()
+		require.Empty(t, p.Errors())
+
+		r.Resolve(root)
+		assert.Empty(t, r.Errors())
+	}
+}
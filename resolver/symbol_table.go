@@ -0,0 +1,123 @@
+package resolver
+
+import (
+	"github.com/maybe-joe/monkey/token"
+)
+
+type Scope string
+
+const (
+	// GlobalScope, bound at the top level of the program.
+	GlobalScope Scope = "GLOBAL"
+	// LocalScope, bound by the function being resolved, as a parameter or a let in its body.
+	LocalScope Scope = "LOCAL"
+	// FreeScope, bound by an enclosing function and captured by the closure.
+	FreeScope Scope = "FREE"
+	// BuiltinScope, provided by the language.
+	BuiltinScope Scope = "BUILTIN"
+)
+
+// Builtins, the names provided by the language, in slot order.
+var Builtins = []string{"quote", "unquote"}
+
+// Symbol, a name bound in a scope.
+type Symbol struct {
+	Name  string
+	Scope Scope
+	// Index, the slot holding the value in its scope. Binding a name again in the
+	// same scope reuses its slot, for free symbols it is the index into Free.
+	Index int
+	// Span, the location of the identifier that bound the name, empty for builtins.
+	Span token.Span
+}
+
+// SymbolTable, the names bound in the program, in one function or in a block with its own scope.
+type SymbolTable struct {
+	Outer *SymbolTable
+	// Free, the symbols of the enclosing tables captured by this one, in slot order.
+	Free []*Symbol
+
+	store map[string]*Symbol
+	slots int
+	// block, true for the table of a block with its own scope, rather than of a function.
+	block bool
+}
+
+// NewSymbolTable, returns the table for the top level of a program with the builtins defined.
+func NewSymbolTable() *SymbolTable {
+	t := &SymbolTable{store: map[string]*Symbol{}}
+	for i, name := range Builtins {
+		t.store[name] = &Symbol{Name: name, Scope: BuiltinScope, Index: i}
+	}
+
+	return t
+}
+
+// NewEnclosedSymbolTable, returns the table for a function inside outer.
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{Outer: outer, store: map[string]*Symbol{}}
+}
+
+// NewBlockSymbolTable, returns the table for a block with a scope of its own inside outer,
+// such as the body of a for. Names it does not bind are found in outer as if from outer itself.
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{Outer: outer, store: map[string]*Symbol{}, block: true}
+}
+
+// Define, binds name in the table. The symbol returned is new each time, so each
+// binding can be told apart, but binding a name again reuses its slot.
+func (t *SymbolTable) Define(name string, span token.Span) *Symbol {
+	scope := LocalScope
+	if t.Outer == nil {
+		scope = GlobalScope
+	}
+
+	index := t.slots
+	if existing, ok := t.store[name]; ok && existing.Scope == scope {
+		index = existing.Index
+	} else {
+		t.slots++
+	}
+
+	s := &Symbol{Name: name, Scope: scope, Index: index, Span: span}
+	t.store[name] = s
+	return s
+}
+
+// Resolve, finds the symbol name refers to, capturing it as a free symbol when it is
+// local to an enclosing function.
+func (t *SymbolTable) Resolve(name string) (*Symbol, bool) {
+	return t.resolve(name, func(*Symbol) {})
+}
+
+// resolve, as Resolve, calling visit with the symbol found before any capture.
+func (t *SymbolTable) resolve(name string, visit func(*Symbol)) (*Symbol, bool) {
+	if s, ok := t.store[name]; ok {
+		visit(s)
+		return s, true
+	}
+
+	if t.Outer == nil {
+		return nil, false
+	}
+
+	s, ok := t.Outer.resolve(name, visit)
+	if !ok || t.block || s.Scope == GlobalScope || s.Scope == BuiltinScope {
+		return s, ok
+	}
+
+	return t.defineFree(s), true
+}
+
+func (t *SymbolTable) defineFree(original *Symbol) *Symbol {
+	t.Free = append(t.Free, original)
+
+	s := &Symbol{Name: original.Name, Scope: FreeScope, Index: len(t.Free) - 1, Span: original.Span}
+	t.store[original.Name] = s
+	return s
+}
+
+// Slots, the number of slots needed for the names bound in the table.
+func (t *SymbolTable) Slots() int {
+	return t.slots
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/evaluator"
+	"github.com/maybe-joe/monkey/object"
+	"github.com/maybe-joe/monkey/optimizer"
+	"github.com/maybe-joe/monkey/resolver"
+)
+
+func runScript(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	format := flags.String("diagnostics", "text", "how to report problems, text or json")
+	optimize := flags.String("optimize", "", "optimisations to run, a comma separated list of fold, branches, unreachable, inline or all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	src, err := readSource(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	root, err := parse(src, *format)
+	if err != nil {
+		return err
+	}
+
+	macros := object.NewEnvironment()
+	evaluator.DefineMacros(root, macros)
+
+	expanded, failure := evaluator.ExpandMacros(root, macros)
+	if failure != nil {
+		return report(src, []diagnostics.Diagnostic{failure.Diagnostic(src)}, *format)
+	}
+
+	// Report names that are not bound before running anything, unused bindings are only warnings.
+	r := resolver.New()
+	r.Resolve(expanded)
+	if err := report(src, r.Errors(), *format); err != nil {
+		return err
+	}
+
+	passes, err := optimizer.ParsePasses(*optimize)
+	if err != nil {
+		return err
+	}
+
+	switch result := evaluator.Eval(optimizer.Optimize(expanded, passes), object.NewEnvironment()).(type) {
+	case nil:
+	case *object.Error:
+		return report(src, []diagnostics.Diagnostic{result.Diagnostic(src)}, *format)
+	default:
+		fmt.Println(result.Inspect())
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/maybe-joe/monkey/server"
+)
+
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", "tcp://127.0.0.1:4040", "address to listen on, tcp://host:port or unix:///path")
+	idle := flags.Duration("idle-timeout", 10*time.Minute, "close sessions without input for this long, 0 to disable")
+	grace := flags.Duration("shutdown-timeout", 5*time.Second, "how long to wait for sessions to finish on shutdown")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	l, err := server.Listen(*listen)
+	if err != nil {
+		return err
+	}
+
+	s := server.New()
+	s.IdleTimeout = *idle
+	s.Banner = "This is the Monkey programming language!\n"
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() { errs <- s.Serve(l) }()
+
+	fmt.Printf("Listening on %s://%s\n", l.Addr().Network(), l.Addr())
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), *grace)
+	defer cancel()
+
+	if err := s.Shutdown(shutdown); err != nil {
+		return err
+	}
+
+	if err := <-errs; !errors.Is(err, server.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
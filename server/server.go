@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maybe-joe/monkey/repl"
+)
+
+// ErrServerClosed is returned by Serve once Shutdown has been called.
+var ErrServerClosed = errors.New("server closed")
+
+// Server, accepts connections and runs an isolated REPL session on each one.
+type Server struct {
+	// Banner, written to each connection when its session starts.
+	Banner string
+	// Prompt, written to each connection before every line is read.
+	Prompt string
+	// IdleTimeout, ends a session when no input arrives for this long.
+	// Zero means sessions never time out.
+	IdleTimeout time.Duration
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	sessions  map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+// New creates a Server with the default REPL prompt and no idle timeout.
+func New() *Server {
+	return &Server{
+		Prompt:    repl.DefaultPrompt,
+		listeners: map[net.Listener]struct{}{},
+		sessions:  map[net.Conn]struct{}{},
+	}
+}
+
+// Listen opens a listener for the given address.
+// Addresses take the form "tcp://host:port" or "unix:///path/to/socket",
+// an address without a scheme is treated as TCP.
+func Listen(address string) (net.Listener, error) {
+	network, addr, ok := strings.Cut(address, "://")
+	if !ok {
+		network, addr = "tcp", address
+	}
+
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return net.Listen(network, addr)
+	default:
+		return nil, fmt.Errorf("unsupported network %q in %q", network, address)
+	}
+}
+
+// Serve accepts connections on l until Shutdown is called, starting a session for each.
+// It always returns a non-nil error, ErrServerClosed after a Shutdown.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !s.open(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go s.session(conn)
+	}
+}
+
+// Shutdown stops accepting new connections and waits for open sessions to finish.
+// If ctx is done first the remaining connections are closed and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for conn := range s.sessions {
+			conn.Close()
+		}
+		s.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// session, runs a REPL over conn until the client disconnects or goes idle.
+// Every session gets its own REPL, so no state is shared between connections.
+func (s *Server) session(conn net.Conn) {
+	defer s.close(conn)
+
+	r := repl.New(&idleReader{conn: conn, timeout: s.IdleTimeout}, conn)
+	r.Prompt = s.Prompt
+	r.Banner = s.Banner
+
+	var ne net.Error
+	if err := r.Run(); errors.As(err, &ne) && ne.Timeout() {
+		fmt.Fprintf(conn, "\nsession idle for %s, closing\n", s.IdleTimeout)
+	}
+}
+
+func (s *Server) track(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listeners, l)
+}
+
+func (s *Server) open(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.sessions[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *Server) close(conn net.Conn) {
+	conn.Close()
+
+	s.mu.Lock()
+	delete(s.sessions, conn)
+	s.mu.Unlock()
+
+	s.wg.Done()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// idleReader, pushes the read deadline of conn forward before every read.
+type idleReader struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.timeout > 0 {
+		if err := r.conn.SetReadDeadline(time.Now().Add(r.timeout)); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.conn.Read(p)
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func start(t *testing.T, s *Server, address string) net.Addr {
+	t.Helper()
+
+	l, err := Listen(address)
+	require.NoError(t, err)
+
+	errs := make(chan error, 1)
+	go func() { errs <- s.Serve(l) }()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		s.Shutdown(ctx)
+		assert.ErrorIs(t, <-errs, ErrServerClosed)
+	})
+
+	return l.Addr()
+}
+
+func Test_Server_Session(t *testing.T) {
+	s := New()
+	s.Banner = "welcome\n"
+	addr := start(t, s, "tcp://127.0.0.1:0")
+
+	conn, err := net.Dial(addr.Network(), addr.String())
+	require.NoError(t, err)
+
+	_, err = io.WriteString(conn, "let x = 5;\nx * 2\n")
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "welcome\n>> >> 10\n>> ", string(out))
+}
+
+func Test_Server_Unix(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "monkey.sock")
+	addr := start(t, New(), "unix://"+socket)
+
+	conn, err := net.Dial(addr.Network(), addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	prompt := make([]byte, 3)
+	_, err = io.ReadFull(conn, prompt)
+	require.NoError(t, err)
+	assert.Equal(t, ">> ", string(prompt))
+}
+
+func Test_Server_ConcurrentSessions(t *testing.T) {
+	addr := start(t, New(), "127.0.0.1:0")
+
+	first, err := net.Dial(addr.Network(), addr.String())
+	require.NoError(t, err)
+	defer first.Close()
+
+	second, err := net.Dial(addr.Network(), addr.String())
+	require.NoError(t, err)
+	defer second.Close()
+
+	// Bindings made in one session are not visible in another.
+	io.WriteString(first, "let x = 1; x\n")
+	io.WriteString(second, "x\n")
+
+	line := func(conn net.Conn) string {
+		text, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		return text
+	}
+
+	assert.Equal(t, ">> 1\n", line(first))
+	assert.Equal(t, ">> error: identifier not found: x\n", line(second))
+}
+
+func Test_Server_IdleTimeout(t *testing.T) {
+	s := New()
+	s.IdleTimeout = 50 * time.Millisecond
+	addr := start(t, s, "127.0.0.1:0")
+
+	conn, err := net.Dial(addr.Network(), addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(out), "session idle for 50ms, closing\n"), string(out))
+}
+
+func Test_Server_Shutdown(t *testing.T) {
+	s := New()
+
+	l, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+
+	errs := make(chan error, 1)
+	go func() { errs <- s.Serve(l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Wait for the session to start before shutting down.
+	prompt := make([]byte, 3)
+	_, err = io.ReadFull(conn, prompt)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The open session never finishes by itself, so it is closed once ctx expires.
+	assert.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, <-errs, ErrServerClosed)
+
+	_, err = net.Dial("tcp", l.Addr().String())
+	assert.Error(t, err)
+
+	_, err = io.ReadAll(conn)
+	assert.NoError(t, err)
+}
+
+func Test_Listen_UnsupportedNetwork(t *testing.T) {
+	_, err := Listen("udp://127.0.0.1:0")
+	assert.EqualError(t, err, `unsupported network "udp" in "udp://127.0.0.1:0"`)
+}
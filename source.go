@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+	"github.com/maybe-joe/monkey/highlight"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+)
+
+// readSource, reads the named file or standard input when name is empty or "-".
+func readSource(name string) (*diagnostics.Source, error) {
+	if name == "" || name == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		return diagnostics.NewSource("<stdin>", string(b)), err
+	}
+
+	b, err := os.ReadFile(name)
+	return diagnostics.NewSource(name, string(b)), err
+}
+
+// parse, parses the source and reports any problems found to stderr.
+func parse(src *diagnostics.Source, format string) (*ast.RootNode, error) {
+	p := parser.New(token.NewTokenizer(src.Code))
+	root := p.Parse()
+
+	if err := report(src, p.Diagnostics(), format); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// report, writes diags to stderr as text or json, returning an error if there were any.
+func report(src *diagnostics.Source, diags []diagnostics.Diagnostic, format string) error {
+	if len(diags) == 0 {
+		return nil
+	}
+
+	switch format {
+	case "json":
+		if err := diagnostics.WriteJSON(os.Stderr, src, diags); err != nil {
+			return err
+		}
+	case "text":
+		renderer := diagnostics.NewRenderer(os.Stderr, src)
+		renderer.Formatter = highlight.For(os.Stderr)
+		renderer.Render(diags...)
+	default:
+		return fmt.Errorf("unknown diagnostics format %q", format)
+	}
+
+	return fmt.Errorf("%s has %d problem(s)", src.Name, len(diags))
+}
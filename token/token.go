@@ -17,6 +17,16 @@ const (
 	BANG     TokenType = "!"
 	ASTERISK TokenType = "*"
 	SLASH    TokenType = "/"
+	ARROW    TokenType = "->"
+	DOTDOT   TokenType = ".."
+	COALESCE TokenType = "??"
+	QUESTION TokenType = "?"
+
+	// Compound assignments
+	PLUS_ASSIGN     TokenType = "+="
+	MINUS_ASSIGN    TokenType = "-="
+	ASTERISK_ASSIGN TokenType = "*="
+	SLASH_ASSIGN    TokenType = "/="
 
 	// Comparisons
 	LT     TokenType = "<"
@@ -27,6 +37,7 @@ const (
 	// Delimiters
 	COMMA     TokenType = ","
 	SEMICOLON TokenType = ";"
+	COLON     TokenType = ":"
 
 	LPAREN TokenType = "("
 	RPAREN TokenType = ")"
@@ -41,6 +52,13 @@ const (
 	IF       TokenType = "IF"
 	ELSE     TokenType = "ELSE"
 	RETURN   TokenType = "RETURN"
+	MACRO    TokenType = "MACRO"
+	WHILE    TokenType = "WHILE"
+	BREAK    TokenType = "BREAK"
+	CONTINUE TokenType = "CONTINUE"
+	FOR      TokenType = "FOR"
+	IN       TokenType = "IN"
+	NULL     TokenType = "NULL"
 )
 
 type Token struct {
@@ -48,6 +66,25 @@ type Token struct {
 	Literal string
 }
 
+// Span, a half open range [Start, End) of byte offsets into the code.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Shift, returns the span moved by delta bytes.
+func (s Span) Shift(delta int) Span {
+	return Span{Start: s.Start + delta, End: s.End + delta}
+}
+
+// Comment, a comment in the code. Comments are not tokens, the tokenizer collects
+// them as it skips over them.
+type Comment struct {
+	// Text, the comment including the leading //.
+	Text string
+	Span Span
+}
+
 func (t Token) Is(typ TokenType) bool {
 	return t.Type == typ
 }
@@ -72,6 +109,22 @@ func Assignment() Token {
 	return Token{Type: ASSIGN}
 }
 
+func PlusAssignment() Token {
+	return Token{Type: PLUS_ASSIGN}
+}
+
+func MinusAssignment() Token {
+	return Token{Type: MINUS_ASSIGN}
+}
+
+func AsteriskAssignment() Token {
+	return Token{Type: ASTERISK_ASSIGN}
+}
+
+func SlashAssignment() Token {
+	return Token{Type: SLASH_ASSIGN}
+}
+
 func Plus() Token {
 	return Token{Type: PLUS}
 }
@@ -80,6 +133,22 @@ func Minus() Token {
 	return Token{Type: MINUS}
 }
 
+func Arrow() Token {
+	return Token{Type: ARROW}
+}
+
+func DotDot() Token {
+	return Token{Type: DOTDOT}
+}
+
+func Coalesce() Token {
+	return Token{Type: COALESCE}
+}
+
+func Question() Token {
+	return Token{Type: QUESTION}
+}
+
 func Bang() Token {
 	return Token{Type: BANG}
 }
@@ -132,6 +201,10 @@ func Semicolon() Token {
 	return Token{Type: SEMICOLON}
 }
 
+func Colon() Token {
+	return Token{Type: COLON}
+}
+
 func Function() Token {
 	return Token{Type: FUNCTION}
 }
@@ -160,6 +233,34 @@ func Return() Token {
 	return Token{Type: RETURN}
 }
 
+func Macro() Token {
+	return Token{Type: MACRO}
+}
+
+func While() Token {
+	return Token{Type: WHILE}
+}
+
+func Break() Token {
+	return Token{Type: BREAK}
+}
+
+func Continue() Token {
+	return Token{Type: CONTINUE}
+}
+
+func For() Token {
+	return Token{Type: FOR}
+}
+
+func In() Token {
+	return Token{Type: IN}
+}
+
+func Null() Token {
+	return Token{Type: NULL}
+}
+
 func Integer(literal string) Token {
 	return Token{Type: INT, Literal: literal}
 }
@@ -11,6 +11,10 @@ type Tokenizer struct {
 	cursor int
 	// peek, one char lookahead.
 	peek int
+	// start, the index in code where the most recent token began.
+	start int
+	// comments, the comments skipped over so far.
+	comments []Comment
 }
 
 // NewTokenizer creates a new Tokenizer for the given code.
@@ -30,6 +34,26 @@ func NewTokenizer(code string) *Tokenizer {
 	return tz
 }
 
+// NewTokenizerAt creates a new Tokenizer for the given code that starts reading at offset,
+// as when only the code after an edit needs tokenizing again. Spans are offsets into the whole code.
+func NewTokenizerAt(code string, offset int) *Tokenizer {
+	offset = max(0, min(offset, len(code)))
+
+	tz := &Tokenizer{
+		code:   code,
+		cursor: offset,
+		peek:   offset + 1,
+		start:  offset,
+		char:   0, // EOF
+	}
+
+	if offset < len(tz.code) {
+		tz.char = tz.code[offset]
+	}
+
+	return tz
+}
+
 // Advance the tokenizer to the next character.
 func (tz *Tokenizer) Advance() {
 	if tz.peek >= len(tz.code) {
@@ -51,11 +75,40 @@ func (tz *Tokenizer) Peek() byte {
 	}
 }
 
-// Whitespace, skips over whitespace characters.
+// Span, returns the location in the code of the most recent token returned by Next.
+func (tz *Tokenizer) Span() Span {
+	return Span{Start: tz.start, End: min(tz.cursor, len(tz.code))}
+}
+
+// Whitespace, skips over whitespace characters and comments.
 func (tz *Tokenizer) Whitespace() {
-	for isWhitespace(tz.char) {
+	for {
+		switch {
+		case isWhitespace(tz.char):
+			tz.Advance()
+		case tz.char == '/' && tz.Peek() == '/':
+			tz.Comment()
+		default:
+			return
+		}
+	}
+}
+
+// Comment, reads a comment running from // to the end of the line.
+func (tz *Tokenizer) Comment() {
+	start := tz.cursor
+
+	for tz.char != '\n' && tz.char != 0 {
 		tz.Advance()
 	}
+
+	end := min(tz.cursor, len(tz.code))
+	tz.comments = append(tz.comments, Comment{Text: tz.code[start:end], Span: Span{Start: start, End: end}})
+}
+
+// Comments, returns the comments skipped over by the calls to Next so far.
+func (tz *Tokenizer) Comments() []Comment {
+	return tz.comments
 }
 
 // Identifier, reads an identifier from the code and returns it as a Token.
@@ -87,17 +140,43 @@ func (tz *Tokenizer) Next() Token {
 	// Skip whitespace
 	tz.Whitespace()
 
+	tz.start = min(tz.cursor, len(tz.code))
+
 	switch tz.char {
 	case 0:
 		t = Eof()
 	case '+':
-		t = Plus()
+		if tz.Peek() == '=' {
+			tz.Advance()
+			t = PlusAssignment()
+		} else {
+			t = Plus()
+		}
 	case '-':
-		t = Minus()
+		switch tz.Peek() {
+		case '>':
+			tz.Advance()
+			t = Arrow()
+		case '=':
+			tz.Advance()
+			t = MinusAssignment()
+		default:
+			t = Minus()
+		}
 	case '*':
-		t = Asterisk()
+		if tz.Peek() == '=' {
+			tz.Advance()
+			t = AsteriskAssignment()
+		} else {
+			t = Asterisk()
+		}
 	case '/':
-		t = Slash()
+		if tz.Peek() == '=' {
+			tz.Advance()
+			t = SlashAssignment()
+		} else {
+			t = Slash()
+		}
 	case '<':
 		t = LessThan()
 	case '>':
@@ -114,6 +193,22 @@ func (tz *Tokenizer) Next() Token {
 		t = Comma()
 	case ';':
 		t = Semicolon()
+	case ':':
+		t = Colon()
+	case '.':
+		if tz.Peek() == '.' {
+			tz.Advance()
+			t = DotDot()
+		} else {
+			t = Illegal(tz.char)
+		}
+	case '?':
+		if tz.Peek() == '?' {
+			tz.Advance()
+			t = Coalesce()
+		} else {
+			t = Question()
+		}
 	case '=':
 		if tz.Peek() == '=' {
 			tz.Advance()
@@ -147,6 +242,20 @@ func (tz *Tokenizer) Next() Token {
 				return True()
 			case "false":
 				return False()
+			case "null":
+				return Null()
+			case "macro":
+				return Macro()
+			case "while":
+				return While()
+			case "break":
+				return Break()
+			case "continue":
+				return Continue()
+			case "for":
+				return For()
+			case "in":
+				return In()
 			}
 		} else if isDigit(tz.char) {
 			return Integer(tz.Number())
@@ -11,7 +11,7 @@ func Test_Tokenizer_Empty(t *testing.T) {
 }
 
 func Test_Tokenizer_Next(t *testing.T) {
-	tz := NewTokenizer("= + ( ) { } , ; fn let aAbBcC_ 9 1 ! - / * < > == !=")
+	tz := NewTokenizer("= + ( ) { } , ; fn let aAbBcC_ 9 1 ! - / * < > == != macro : -> while break continue for in .. += -= *= /= null ?? ? .")
 
 	testcases := []struct {
 		name     string
@@ -38,6 +38,23 @@ func Test_Tokenizer_Next(t *testing.T) {
 		{"Greater Than", GreaterThan()},
 		{"Equal", Equal()},
 		{"Not Equal", NotEqual()},
+		{"Macro", Macro()},
+		{"Colon", Colon()},
+		{"Arrow", Arrow()},
+		{"While", While()},
+		{"Break", Break()},
+		{"Continue", Continue()},
+		{"For", For()},
+		{"In", In()},
+		{"DotDot", DotDot()},
+		{"Plus Assignment", PlusAssignment()},
+		{"Minus Assignment", MinusAssignment()},
+		{"Asterisk Assignment", AsteriskAssignment()},
+		{"Slash Assignment", SlashAssignment()},
+		{"Null", Null()},
+		{"Coalesce", Coalesce()},
+		{"Question", Question()},
+		{"Dot", Illegal('.')},
 		{"Eof", Eof()},
 	}
 
@@ -91,3 +108,41 @@ func Test_Tokenizer_Tokenize_SimpleProgram(t *testing.T) {
 
 	assert.Equal(t, expected, NewTokenizer(code).Tokenize())
 }
+
+func Test_Tokenizer_Span(t *testing.T) {
+	tz := NewTokenizer("let x  = 10;\n")
+
+	expected := []Span{
+		{Start: 0, End: 3},
+		{Start: 4, End: 5},
+		{Start: 7, End: 8},
+		{Start: 9, End: 11},
+		{Start: 11, End: 12},
+		{Start: 13, End: 13},
+	}
+
+	for _, span := range expected {
+		tz.Next()
+		assert.Equal(t, span, tz.Span())
+	}
+}
+
+func Test_Tokenizer_Comments(t *testing.T) {
+	tz := NewTokenizer("// first\nlet a = 1; // second\n//")
+
+	assert.Equal(t, []Token{Let(), Identifier("a"), Assignment(), Integer("1"), Semicolon(), Eof()}, tz.Tokenize())
+	assert.Equal(t, []Comment{
+		{Text: "// first", Span: Span{Start: 0, End: 8}},
+		{Text: "// second", Span: Span{Start: 20, End: 29}},
+		{Text: "//", Span: Span{Start: 30, End: 32}},
+	}, tz.Comments())
+}
+
+func Test_Tokenizer_At(t *testing.T) {
+	tz := NewTokenizerAt("let x = 10; // ten\n", 8)
+
+	assert.Equal(t, Integer("10"), tz.Next())
+	assert.Equal(t, Span{Start: 8, End: 10}, tz.Span())
+	assert.Equal(t, []Token{Semicolon(), Eof()}, tz.Tokenize())
+	assert.Equal(t, []Comment{{Text: "// ten", Span: Span{Start: 12, End: 18}}}, tz.Comments())
+}
@@ -0,0 +1,617 @@
+package types
+
+import (
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/diagnostics"
+)
+
+// Checker, infers the types of a program Hindley-Milner style, reporting the places
+// where values are used at types they cannot have, such as 5 + true.
+//
+// Functions bound by let are generalised, so fn(x) { x } can be called with an
+// int in one place and a bool in another, unless the binding is assigned to, since
+// the value assigned may only work at one type. Names that are not bound are
+// given an unknown type and left for the resolver to report.
+type Checker struct {
+	scopes      []map[string]*Scheme
+	returns     []Type
+	types       map[ast.Node]Type
+	diagnostics []diagnostics.Diagnostic
+	next        int
+
+	// assigned, the lets whose bindings are assigned to, by the identifier they bind.
+	assigned map[*ast.IdentifierNode]bool
+}
+
+func New() *Checker {
+	return &Checker{
+		scopes:   []map[string]*Scheme{{}},
+		types:    map[ast.Node]Type{},
+		assigned: map[*ast.IdentifierNode]bool{},
+	}
+}
+
+// Check, infers the types in root. Checking several programs with the same checker
+// runs them in the same global scope, as the lines typed into a repl.
+func (c *Checker) Check(root *ast.RootNode) {
+	c.returns = append(c.returns, c.Fresh())
+	defer func() { c.returns = c.returns[:len(c.returns)-1] }()
+
+	c.Assigned(root, []map[string]*ast.IdentifierNode{{}})
+	c.Statements(root.Statements)
+}
+
+// Assigned, records the lets in node whose bindings are assigned to, resolving names
+// in the scopes the checker will give them. Names bound by an earlier program are not
+// in scopes, those are left to Assign.
+func (c *Checker) Assigned(node ast.Node, scopes []map[string]*ast.IdentifierNode) {
+	switch n := node.(type) {
+	case *ast.LetNode:
+		if n.Identifier == nil {
+			c.Assigned(n.Value, scopes)
+			return
+		}
+
+		scope := scopes[len(scopes)-1]
+		if _, ok := n.Value.(*ast.FunctionNode); ok {
+			// A function can refer to the name it is bound to.
+			scope[n.Identifier.Value] = n.Identifier
+		}
+		c.Assigned(n.Value, scopes)
+		scope[n.Identifier.Value] = n.Identifier
+	case *ast.FunctionNode:
+		scope := map[string]*ast.IdentifierNode{}
+		for _, param := range n.Parameters {
+			scope[param.Value] = param
+		}
+
+		if n.Body != nil {
+			c.Assigned(n.Body, append(scopes, scope))
+		}
+	case *ast.ForNode:
+		c.Assigned(n.Iterable, scopes)
+
+		scope := map[string]*ast.IdentifierNode{}
+		if n.Value != nil {
+			scope[n.Value.Value] = n.Value
+		}
+
+		if n.Body != nil {
+			c.Assigned(n.Body, append(scopes, scope))
+		}
+	case *ast.AssignNode:
+		for i := len(scopes) - 1; i >= 0; i-- {
+			if id, ok := scopes[i][n.Name.Value]; ok {
+				c.assigned[id] = true
+				break
+			}
+		}
+		c.Assigned(n.Value, scopes)
+	default:
+		for _, child := range ast.Children(node) {
+			c.Assigned(child, scopes)
+		}
+	}
+}
+
+// Fresh, returns a new unknown type.
+func (c *Checker) Fresh() *Variable {
+	c.next++
+	return &Variable{ID: c.next}
+}
+
+// Statements, checks a list of statements returning the type of the value of the list.
+func (c *Checker) Statements(stmts []ast.Statement) Type {
+	var t Type = Null
+
+	for _, stmt := range stmts {
+		t = c.Statement(stmt)
+	}
+
+	return t
+}
+
+func (c *Checker) Statement(stmt ast.Statement) Type {
+	switch n := stmt.(type) {
+	case *ast.LetNode:
+		c.Let(n)
+		return Null
+	case *ast.ReturnNode:
+		c.Return(n)
+		// Control leaves with the return, so the statement can stand in for any value.
+		return c.Fresh()
+	case *ast.ExpressionStatementNode:
+		return c.Expression(n.Expression)
+	case *ast.WhileNode:
+		c.While(n)
+		return Null
+	case *ast.ForNode:
+		c.For(n)
+		return Null
+	case *ast.BreakNode, *ast.ContinueNode:
+		// As with return, control leaves so the statement can stand in for any value.
+		return c.Fresh()
+	default:
+		return c.Fresh()
+	}
+}
+
+func (c *Checker) Let(n *ast.LetNode) {
+	if n.Identifier == nil {
+		c.Expression(n.Value)
+		return
+	}
+
+	name := n.Identifier.Value
+	scope := c.scopes[len(c.scopes)-1]
+
+	var annotation Type
+	if n.Annotation != nil {
+		annotation = c.Annotation(n.Annotation)
+	}
+
+	var t Type
+	if fn, ok := n.Value.(*ast.FunctionNode); ok {
+		// Bind the name while checking the function so it can call itself.
+		var self Type = c.Fresh()
+		if annotation != nil {
+			self = annotation
+		}
+		scope[name] = &Scheme{Type: self}
+
+		t = c.Expression(fn)
+		c.Expect(self, t, fn)
+		delete(scope, name)
+	} else {
+		t = c.Expression(n.Value)
+		if annotation != nil {
+			c.Expect(annotation, t, n.Value)
+		}
+	}
+
+	c.types[n.Identifier] = t
+	if c.assigned[n.Identifier] {
+		scope[name] = &Scheme{Type: t}
+	} else {
+		scope[name] = c.Generalize(t)
+	}
+}
+
+func (c *Checker) Return(n *ast.ReturnNode) {
+	t := c.Expression(n.Value)
+	if n.Value != nil {
+		c.Expect(c.returns[len(c.returns)-1], t, n.Value)
+	}
+}
+
+// Expression, infers the type of n.
+func (c *Checker) Expression(n ast.Expression) Type {
+	t := c.expression(n)
+	if n != nil {
+		c.types[n] = t
+	}
+
+	return t
+}
+
+func (c *Checker) expression(node ast.Expression) Type {
+	switch n := node.(type) {
+	case *ast.IntegerNode:
+		return Int
+	case *ast.BooleanNode:
+		return Bool
+	case *ast.NullNode:
+		return Null
+	case *ast.IdentifierNode:
+		return c.Identifier(n)
+	case *ast.PrefixNode:
+		return c.Prefix(n)
+	case *ast.InfixNode:
+		return c.Infix(n)
+	case *ast.IfNode:
+		return c.If(n)
+	case *ast.ConditionalNode:
+		return c.Conditional(n)
+	case *ast.AssignNode:
+		return c.Assign(n)
+	case *ast.RangeNode:
+		c.Expect(Int, c.Expression(n.Start), n.Start)
+		c.Expect(Int, c.Expression(n.End), n.End)
+		return Range
+	case *ast.FunctionNode:
+		return c.Function(n)
+	case *ast.CallNode:
+		return c.Call(n)
+	case nil:
+		return Null
+	default:
+		return c.Fresh()
+	}
+}
+
+func (c *Checker) Identifier(n *ast.IdentifierNode) Type {
+	if s, ok := c.Lookup(n.Value); ok {
+		return c.Instantiate(s)
+	}
+
+	return c.Fresh()
+}
+
+// Lookup, returns the scheme of the innermost binding of name.
+func (c *Checker) Lookup(name string) (*Scheme, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if s, ok := c.scopes[i][name]; ok {
+			return s, true
+		}
+	}
+
+	return nil, false
+}
+
+func (c *Checker) Prefix(n *ast.PrefixNode) Type {
+	right := c.Expression(n.Right)
+
+	switch n.Operator {
+	case "-":
+		c.Expect(Int, right, n.Right)
+		return Int
+	case "!":
+		// Any value may be negated, by whether it is truthy.
+		return Bool
+	default:
+		return c.Fresh()
+	}
+}
+
+func (c *Checker) Infix(n *ast.InfixNode) Type {
+	left := c.Expression(n.Left)
+	right := c.Expression(n.Right)
+
+	switch n.Operator {
+	case "+", "-", "*", "/":
+		c.Expect(Int, left, n.Left)
+		c.Expect(Int, right, n.Right)
+		return Int
+	case "<", ">":
+		c.Expect(Int, left, n.Left)
+		c.Expect(Int, right, n.Right)
+		return Bool
+	case "??":
+		// Without a type for values that may be null, either side being null
+		// means the other is the one used.
+		switch {
+		case Prune(left) == Null:
+			return right
+		case Prune(right) == Null:
+			return left
+		default:
+			c.Expect(left, right, n.Right)
+			return left
+		}
+	case "==", "!=":
+		// Anything can be compared with null, which does not make the other side null.
+		if Prune(left) == Null || Prune(right) == Null {
+			return Bool
+		}
+		if !c.Unify(left, right) {
+			names := Strings(left, right)
+			c.Error(diagnostics.Errorf(n.Span, "cannot compare %s with %s", names[0], names[1]).
+				WithLabel(n.Left.Location(), names[0]).
+				WithLabel(n.Right.Location(), names[1]),
+			)
+		}
+		return Bool
+	default:
+		return c.Fresh()
+	}
+}
+
+// Assign, checks that the value assigned has the type of the name, giving that type.
+// Compound assignments such as += are arithmetic, so both sides are ints.
+func (c *Checker) Assign(n *ast.AssignNode) Type {
+	// A name bound before it was known to be assigned, by an earlier line of a repl,
+	// stops being generalised so the value is checked against the binding's own type.
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if s, ok := c.scopes[i][n.Name.Value]; ok {
+			c.scopes[i][n.Name.Value] = &Scheme{Type: s.Type}
+			break
+		}
+	}
+
+	name := c.Expression(n.Name)
+	value := c.Expression(n.Value)
+
+	if n.Operator != "=" {
+		c.Expect(Int, name, n.Name)
+		c.Expect(Int, value, n.Value)
+		return Int
+	}
+
+	c.Expect(name, value, n.Value)
+	return name
+}
+
+func (c *Checker) If(n *ast.IfNode) Type {
+	c.Expect(Bool, c.Expression(n.Condition), n.Condition)
+
+	consequence := c.Block(n.Consequence)
+	if n.Alternative == nil {
+		return Null
+	}
+
+	alternative := c.Block(n.Alternative)
+	if !c.Unify(consequence, alternative) {
+		names := Strings(consequence, alternative)
+		c.Error(diagnostics.Errorf(n.Alternative.Span, "if and else have different types, %s and %s", names[0], names[1]).
+			WithMessage(names[1]).
+			WithLabel(n.Consequence.Span, names[0]),
+		)
+	}
+
+	return consequence
+}
+
+func (c *Checker) Conditional(n *ast.ConditionalNode) Type {
+	c.Expect(Bool, c.Expression(n.Condition), n.Condition)
+
+	consequence := c.Expression(n.Consequence)
+	c.Expect(consequence, c.Expression(n.Alternative), n.Alternative)
+
+	return consequence
+}
+
+func (c *Checker) While(n *ast.WhileNode) {
+	c.Expect(Bool, c.Expression(n.Condition), n.Condition)
+	c.Block(n.Body)
+}
+
+// For, checks a for loop. The loop variable is bound in a scope of its own around the body.
+func (c *Checker) For(n *ast.ForNode) {
+	c.Expect(Range, c.Expression(n.Iterable), n.Iterable)
+
+	scope := map[string]*Scheme{}
+	if n.Value != nil {
+		scope[n.Value.Value] = &Scheme{Type: Int}
+		c.types[n.Value] = Int
+	}
+
+	c.scopes = append(c.scopes, scope)
+	defer func() { c.scopes = c.scopes[:len(c.scopes)-1] }()
+
+	c.Block(n.Body)
+}
+
+func (c *Checker) Block(n *ast.BlockNode) Type {
+	if n == nil {
+		return Null
+	}
+
+	t := c.Statements(n.Statements)
+	c.types[n] = t
+	return t
+}
+
+func (c *Checker) Function(n *ast.FunctionNode) Type {
+	scope := map[string]*Scheme{}
+	params := make([]Type, len(n.Parameters))
+	for i, param := range n.Parameters {
+		var t Type = c.Fresh()
+		if i < len(n.ParameterTypes) && n.ParameterTypes[i] != nil {
+			t = c.Annotation(n.ParameterTypes[i])
+		}
+
+		params[i] = t
+		scope[param.Value] = &Scheme{Type: t}
+		c.types[param] = t
+	}
+
+	var ret Type = c.Fresh()
+	if n.ReturnType != nil {
+		ret = c.Annotation(n.ReturnType)
+	}
+
+	c.scopes = append(c.scopes, scope)
+	c.returns = append(c.returns, ret)
+	defer func() {
+		c.scopes = c.scopes[:len(c.scopes)-1]
+		c.returns = c.returns[:len(c.returns)-1]
+	}()
+
+	if n.Body != nil {
+		c.Expect(ret, c.Block(n.Body), n.Body)
+	}
+
+	return &Function{Parameters: params, Return: ret}
+}
+
+func (c *Checker) Call(n *ast.CallNode) Type {
+	if id, ok := n.Function.(*ast.IdentifierNode); ok && id.Value == "quote" {
+		return Quote
+	}
+
+	function := c.Expression(n.Function)
+
+	args := make([]Type, len(n.Arguments))
+	for i, arg := range n.Arguments {
+		args[i] = c.Expression(arg)
+	}
+
+	switch f := Prune(function).(type) {
+	case *Function:
+		if len(f.Parameters) != len(args) {
+			c.Error(diagnostics.Errorf(n.Span, "wrong number of arguments: want %d, got %d", len(f.Parameters), len(args)).
+				WithMessage(String(f)),
+			)
+			return f.Return
+		}
+
+		for i, arg := range n.Arguments {
+			c.Expect(f.Parameters[i], args[i], arg)
+		}
+		return f.Return
+	case *Variable:
+		ret := c.Fresh()
+		c.Expect(&Function{Parameters: args, Return: ret}, f, n.Function)
+		return ret
+	default:
+		c.Error(diagnostics.Errorf(n.Function.Location(), "%s is not a function", String(f)).WithMessage("called here"))
+		return c.Fresh()
+	}
+}
+
+// annotationTypes, the types named in annotations.
+var annotationTypes = map[string]Type{"int": Int, "bool": Bool, "null": Null, "quote": Quote, "range": Range}
+
+// Annotation, returns the type written in an annotation, reporting names that are not types.
+func (c *Checker) Annotation(n *ast.TypeNode) Type {
+	if n.IsFunction() {
+		params := make([]Type, len(n.Parameters))
+		for i, param := range n.Parameters {
+			params[i] = c.Annotation(param)
+		}
+
+		return &Function{Parameters: params, Return: c.Annotation(n.Return)}
+	}
+
+	t, ok := annotationTypes[n.Name]
+	if !ok {
+		c.Error(diagnostics.Errorf(n.Span, "unknown type '%s'", n.Name).WithHint("the types are int, bool, null, quote, range and fn(...) -> ..."))
+		return c.Fresh()
+	}
+
+	return t
+}
+
+// Expect, unifies the type expected at node with the type found there, reporting it if they differ.
+func (c *Checker) Expect(expected, actual Type, node ast.Node) {
+	if c.Unify(expected, actual) {
+		return
+	}
+
+	names := Strings(expected, actual)
+	c.Error(diagnostics.Errorf(node.Location(), "mismatched types: expected %s, found %s", names[0], names[1]).
+		WithMessage("expected " + names[0]),
+	)
+}
+
+// Unify, makes a and b the same type by filling in unknown types, returning false if they cannot be.
+func (c *Checker) Unify(a, b Type) bool {
+	a, b = Prune(a), Prune(b)
+
+	if v, ok := a.(*Variable); ok {
+		if a == b {
+			return true
+		}
+		if occurs(v, b) {
+			return false
+		}
+		v.Instance = b
+		return true
+	}
+
+	if _, ok := b.(*Variable); ok {
+		return c.Unify(b, a)
+	}
+
+	switch a := a.(type) {
+	case *Constructor:
+		return a == b
+	case *Function:
+		f, ok := b.(*Function)
+		if !ok || len(a.Parameters) != len(f.Parameters) {
+			return false
+		}
+
+		for i := range a.Parameters {
+			if !c.Unify(a.Parameters[i], f.Parameters[i]) {
+				return false
+			}
+		}
+		return c.Unify(a.Return, f.Return)
+	default:
+		return false
+	}
+}
+
+// Generalize, returns a scheme for t in which the unknown types that nothing else in
+// scope depends on may differ at each use.
+func (c *Checker) Generalize(t Type) *Scheme {
+	bound := map[*Variable]bool{}
+	for _, scope := range c.scopes {
+		for _, s := range scope {
+			free := map[*Variable]bool{}
+			freeVariables(s.Type, free)
+			for _, v := range s.Variables {
+				delete(free, v)
+			}
+			for v := range free {
+				bound[v] = true
+			}
+		}
+	}
+	for _, ret := range c.returns {
+		freeVariables(ret, bound)
+	}
+
+	free := map[*Variable]bool{}
+	freeVariables(t, free)
+
+	s := &Scheme{Type: t}
+	for v := range free {
+		if !bound[v] {
+			s.Variables = append(s.Variables, v)
+		}
+	}
+
+	return s
+}
+
+// Instantiate, returns the type of s with fresh unknown types in place of its variables.
+func (c *Checker) Instantiate(s *Scheme) Type {
+	if len(s.Variables) == 0 {
+		return s.Type
+	}
+
+	fresh := map[*Variable]Type{}
+	for _, v := range s.Variables {
+		fresh[v] = c.Fresh()
+	}
+
+	return substitute(s.Type, fresh)
+}
+
+func substitute(t Type, with map[*Variable]Type) Type {
+	switch t := Prune(t).(type) {
+	case *Variable:
+		if r, ok := with[t]; ok {
+			return r
+		}
+		return t
+	case *Function:
+		params := make([]Type, len(t.Parameters))
+		for i, param := range t.Parameters {
+			params[i] = substitute(param, with)
+		}
+		return &Function{Parameters: params, Return: substitute(t.Return, with)}
+	default:
+		return t
+	}
+}
+
+func (c *Checker) Error(d diagnostics.Diagnostic) {
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+func (c *Checker) Diagnostics() []diagnostics.Diagnostic {
+	return c.diagnostics
+}
+
+// TypeOf, returns the type inferred for an expression, block, parameter or the name bound by a let.
+func (c *Checker) TypeOf(node ast.Node) (Type, bool) {
+	t, ok := c.types[node]
+	if !ok {
+		return nil, false
+	}
+
+	return Prune(t), true
+}
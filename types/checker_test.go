@@ -0,0 +1,173 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/maybe-joe/monkey/ast"
+	"github.com/maybe-joe/monkey/parser"
+	"github.com/maybe-joe/monkey/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func check(t *testing.T, code string) (*Checker, *ast.RootNode) {
+	t.Helper()
+
+	p := parser.New(token.NewTokenizer(code))
+	root := p.Parse()
+	require.Empty(t, p.Errors())
+
+	c := New()
+	c.Check(root)
+
+	return c, root
+}
+
+// last, returns the type of the value of the last statement, or of the name bound by it.
+func last(t *testing.T, c *Checker, root *ast.RootNode) string {
+	t.Helper()
+
+	var node ast.Node
+	switch stmt := root.Statements[len(root.Statements)-1].(type) {
+	case *ast.LetNode:
+		node = stmt.Identifier
+	case *ast.ExpressionStatementNode:
+		node = stmt.Expression
+	}
+
+	typ, ok := c.TypeOf(node)
+	require.True(t, ok)
+	return String(typ)
+}
+
+func Test_Check(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected string
+	}{
+		{name: "integer", given: "5", expected: "int"},
+		{name: "arithmetic", given: "(5 + 10 * 2) / 3 - -1", expected: "int"},
+		{name: "comparison", given: "1 < 2 == true", expected: "bool"},
+		{name: "bang", given: "!5", expected: "bool"},
+		{name: "if", given: "if (1 > 2) { 10 } else { 20 }", expected: "int"},
+		{name: "if without else", given: "if (true) { 10 }", expected: "null"},
+		{name: "else if", given: "let f = fn(x) { if (x < 0) { false } else if (x > 0) { true } else { false } };", expected: "fn(int) -> bool"},
+		{name: "conditional", given: "let max = fn(a, b) { a > b ? a : b };", expected: "fn(int, int) -> int"},
+		{name: "null", given: "null", expected: "null"},
+		{name: "coalesce null", given: "if (true) { 10 } ?? 0", expected: "int"},
+		{name: "coalesce to null", given: "let f = fn(x) { x ?? null };", expected: "fn(a) -> a"},
+		{name: "compare with null", given: "let x = 1; x == null", expected: "bool"},
+		{name: "compare null with", given: "null != true", expected: "bool"},
+		{name: "compare keeps the type", given: "let f = fn(x) { if (x == null) { 0 } else { x + 1 } };", expected: "fn(int) -> int"},
+		{name: "coalesce unknown", given: "let f = fn(x) { x ?? 0 };", expected: "fn(int) -> int"},
+		{name: "let", given: "let a = 5; let b = a * 2;", expected: "int"},
+		{name: "function", given: "let add = fn(x, y) { x + y };", expected: "fn(int, int) -> int"},
+		{name: "identity", given: "let id = fn(x) { x };", expected: "fn(a) -> a"},
+		{name: "polymorphism", given: "let id = fn(x) { x }; if (id(true)) { id(1) } else { 2 }", expected: "int"},
+		{name: "shadow assigned", given: "let id = fn(x) { x }; let f = fn() { let id = 1; id = 2; }; if (id(true)) { id(1) } else { 2 }", expected: "int"},
+		{name: "parameter assigned", given: "let id = fn(x) { x }; let f = fn(id) { id = 1; }; if (id(true)) { id(1) } else { 2 }", expected: "int"},
+		{name: "higher order", given: "let apply = fn(f, x) { f(x) };", expected: "fn(fn(a) -> b, a) -> b"},
+		{name: "compose", given: "let compose = fn(f, g) { fn(x) { g(f(x)) } };", expected: "fn(fn(a) -> b, fn(b) -> c) -> fn(a) -> c"},
+		{name: "closure", given: "let adder = fn(x) { fn(y) { x + y } }; adder(2)", expected: "fn(int) -> int"},
+		{name: "recursion", given: "let f = fn(n) { if (n < 1) { 0 } else { n + f(n - 1) } };", expected: "fn(int) -> int"},
+		{name: "return", given: "let f = fn(n) { if (n > 0) { return true; } false };", expected: "fn(int) -> bool"},
+		{name: "return in both branches", given: "let f = fn(n) { if (n > 0) { return 1; } else { return 2; } };", expected: "fn(int) -> int"},
+		{name: "ends with let", given: "let f = fn() { let a = 1; };", expected: "fn() -> null"},
+		{name: "quote", given: "quote(1 + true)", expected: "quote"},
+		{name: "annotated let", given: "let a: int = 1;", expected: "int"},
+		{name: "annotated identity", given: "let id = fn(x: int) { x };", expected: "fn(int) -> int"},
+		{name: "annotated result", given: "let f = fn(x) -> bool { x };", expected: "fn(bool) -> bool"},
+		{name: "annotated function", given: "let apply: fn(fn(int) -> int, int) -> int = fn(f, x) { f(x) };", expected: "fn(fn(int) -> int, int) -> int"},
+		{name: "unknown name", given: "let f = fn() { g() };", expected: "fn() -> a"},
+		{name: "range", given: "let r = 0..10;", expected: "range"},
+		{name: "annotated range", given: "let f = fn(r: range, n) { for (x in r) { if (x > n) { return x; } } 0 };", expected: "fn(range, int) -> int"},
+		{name: "assign", given: "let a = true; a = 1 > 2", expected: "bool"},
+		{name: "compound assign", given: "let f = fn(n) { let total = 0; for (i in 0..n) { total += i; } total };", expected: "fn(int) -> int"},
+		{name: "while", given: "let f = fn(n) { while (n > 0) { if (n > 5) { break; } let n = n - 1; } n };", expected: "fn(int) -> int"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, root := check(t, tc.given)
+			assert.Empty(t, c.Diagnostics())
+			assert.Equal(t, tc.expected, last(t, c, root))
+		})
+	}
+}
+
+func Test_Check_Errors(t *testing.T) {
+	testcases := []struct {
+		name     string
+		given    string
+		expected string
+		span     token.Span
+	}{
+		{name: "infix", given: "5 + true", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 4, End: 8}},
+		{name: "prefix", given: "-true", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 1, End: 5}},
+		{name: "compare", given: "1 == true", expected: "cannot compare int with bool", span: token.Span{Start: 0, End: 9}},
+		{name: "condition", given: "if (1) { 2 }", expected: "mismatched types: expected bool, found int", span: token.Span{Start: 4, End: 5}},
+		{name: "while condition", given: "while (1) { 2 }", expected: "mismatched types: expected bool, found int", span: token.Span{Start: 7, End: 8}},
+		{name: "range bound", given: "0..true", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 3, End: 7}},
+		{name: "iterable", given: "for (x in 5) { x }", expected: "mismatched types: expected range, found int", span: token.Span{Start: 10, End: 11}},
+		{name: "loop variable", given: "for (x in 0..2) { x + true }", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 22, End: 26}},
+		{name: "in loop", given: "while (true) { 1 + false; }", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 19, End: 24}},
+		{name: "assign", given: "let a = 1; a = true", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 15, End: 19}},
+		{name: "compound assign", given: "let b = true; b += 1", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 14, End: 15}},
+		{name: "coalesce", given: "1 ?? true", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 5, End: 9}},
+		{name: "else if branches", given: "if (true) { 1 } else if (false) { true } else { 2 }", expected: "if and else have different types, bool and int", span: token.Span{Start: 46, End: 51}},
+		{name: "conditional condition", given: "1 ? 2 : 3", expected: "mismatched types: expected bool, found int", span: token.Span{Start: 0, End: 1}},
+		{name: "conditional branches", given: "true ? 1 : false", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 11, End: 16}},
+		{name: "assigned function", given: "let r = fn(x) { x }; r = fn(x) { x + 1 }; r(true)", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 44, End: 48}},
+		{name: "assigned function used before", given: "let r = fn(x) { x }; let g = fn() { r(true) }; r = fn(x) { x + 1 };", expected: "mismatched types: expected fn(bool) -> bool, found fn(int) -> int", span: token.Span{Start: 51, End: 66}},
+		{name: "branches", given: "if (true) { 1 } else { false }", expected: "if and else have different types, int and bool", span: token.Span{Start: 21, End: 30}},
+		{name: "argument", given: "let f = fn(x) { x + 1 }; f(true)", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 27, End: 31}},
+		{name: "arity", given: "let f = fn(x) { x }; f(1, 2)", expected: "wrong number of arguments: want 1, got 2", span: token.Span{Start: 21, End: 28}},
+		{name: "not a function", given: "let a = 1; a(2)", expected: "int is not a function", span: token.Span{Start: 11, End: 12}},
+		{name: "return", given: "fn(x) { if (x) { return 1; } true }", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 6, End: 35}},
+		{name: "monomorphic parameter", given: "fn(f) { if (f(true)) { f(1) } else { 2 } }", expected: "mismatched types: expected bool, found int", span: token.Span{Start: 25, End: 26}},
+		{name: "annotated let", given: "let a: bool = 1;", expected: "mismatched types: expected bool, found int", span: token.Span{Start: 14, End: 15}},
+		{name: "annotated parameter", given: "fn(x: bool) { x + 1 }", expected: "mismatched types: expected int, found bool", span: token.Span{Start: 14, End: 15}},
+		{name: "annotated result", given: "fn(x) -> bool { x + 1 }", expected: "mismatched types: expected bool, found int", span: token.Span{Start: 14, End: 23}},
+		{name: "annotated function", given: "let f: fn(int) -> int = fn(x, y) { x };", expected: "mismatched types: expected fn(int) -> int, found fn(a, b) -> a", span: token.Span{Start: 24, End: 38}},
+		{name: "unknown type", given: "let a: string = 1;", expected: "unknown type 'string'", span: token.Span{Start: 7, End: 13}},
+		{name: "infinite", given: "fn(f) { f(f) }", expected: "mismatched types: expected fn(a) -> b, found a", span: token.Span{Start: 8, End: 9}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := check(t, tc.given)
+			require.NotEmpty(t, c.Diagnostics())
+
+			d := c.Diagnostics()[0]
+			assert.Equal(t, tc.expected, d.Message)
+
+			span, _ := d.Primary()
+			assert.Equal(t, tc.span, span)
+		})
+	}
+}
+
+func Test_Check_Repl(t *testing.T) {
+	c := New()
+
+	for _, line := range []string{"let id = fn(x) { x };", "id(1) + 1", "id(true) + 1"} {
+		p := parser.New(token.NewTokenizer(line))
+		c.Check(p.Parse())
+	}
+
+	require.Len(t, c.Diagnostics(), 1)
+	assert.Equal(t, "mismatched types: expected int, found bool", c.Diagnostics()[0].Message)
+}
+
+func Test_Check_Repl_Assigned(t *testing.T) {
+	c := New()
+
+	for _, line := range []string{"let r = fn(x) { x };", "r = fn(x) { x + 1 };", "r(true)"} {
+		p := parser.New(token.NewTokenizer(line))
+		c.Check(p.Parse())
+	}
+
+	require.Len(t, c.Diagnostics(), 1)
+	assert.Equal(t, "mismatched types: expected int, found bool", c.Diagnostics()[0].Message)
+}
@@ -0,0 +1,147 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type, the type of a monkey value.
+type Type interface {
+	typ()
+}
+
+// Constructor, a type without parameters such as int.
+type Constructor struct {
+	Name string
+}
+
+// Function, the type of a function taking Parameters and returning Return.
+type Function struct {
+	Parameters []Type
+	Return     Type
+}
+
+// Variable, a type not yet known. Unification sets Instance once it is.
+type Variable struct {
+	ID       int
+	Instance Type
+}
+
+func (*Constructor) typ() {}
+func (*Function) typ()    {}
+func (*Variable) typ()    {}
+
+var (
+	Int  = &Constructor{Name: "int"}
+	Bool = &Constructor{Name: "bool"}
+	// Null, the type of an if without an else and of blocks that end without a value.
+	Null = &Constructor{Name: "null"}
+	// Quote, the type of quoted code.
+	Quote = &Constructor{Name: "quote"}
+	// Range, the type of a range of integers such as 0..10.
+	Range = &Constructor{Name: "range"}
+)
+
+// Prune, follows the instances of variables to the type they stand for,
+// returning t itself if it is not a variable or is still unknown.
+func Prune(t Type) Type {
+	for {
+		v, ok := t.(*Variable)
+		if !ok || v.Instance == nil {
+			return t
+		}
+		t = v.Instance
+	}
+}
+
+// Scheme, a type that may be used at different types, as the identity function
+// fn(x) { x } is used at fn(int) -> int and fn(bool) -> bool.
+// The variables listed are replaced by fresh ones each time the scheme is used.
+type Scheme struct {
+	Variables []*Variable
+	Type      Type
+}
+
+// String, writes t as it is written in annotations, naming unknown types a, b, c and so on
+// in the order they appear.
+func String(t Type) string {
+	return Strings(t)[0]
+}
+
+// Strings, writes several types as String does, with the same names for the same unknown types.
+func Strings(ts ...Type) []string {
+	names := map[*Variable]string{}
+
+	result := make([]string, len(ts))
+	for i, t := range ts {
+		var b strings.Builder
+		write(&b, t, names)
+		result[i] = b.String()
+	}
+
+	return result
+}
+
+func write(b *strings.Builder, t Type, names map[*Variable]string) {
+	switch t := Prune(t).(type) {
+	case *Constructor:
+		b.WriteString(t.Name)
+	case *Function:
+		b.WriteString("fn(")
+		for i, param := range t.Parameters {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			write(b, param, names)
+		}
+		b.WriteString(") -> ")
+		write(b, t.Return, names)
+	case *Variable:
+		name, ok := names[t]
+		if !ok {
+			name = variableName(len(names))
+			names[t] = name
+		}
+		b.WriteString(name)
+	}
+}
+
+// variableName, returns a, b, ... z, then a1, b1 and so on.
+func variableName(i int) string {
+	name := string(rune('a' + i%26))
+	if i >= 26 {
+		name += fmt.Sprint(i / 26)
+	}
+
+	return name
+}
+
+// occurs, returns true if v appears in t.
+func occurs(v *Variable, t Type) bool {
+	switch t := Prune(t).(type) {
+	case *Variable:
+		return t == v
+	case *Function:
+		for _, param := range t.Parameters {
+			if occurs(v, param) {
+				return true
+			}
+		}
+		return occurs(v, t.Return)
+	default:
+		return false
+	}
+}
+
+// freeVariables, adds the unknown types in t to free.
+func freeVariables(t Type, free map[*Variable]bool) {
+	switch t := Prune(t).(type) {
+	case *Variable:
+		free[t] = true
+	case *Function:
+		for _, param := range t.Parameters {
+			freeVariables(param, free)
+		}
+		freeVariables(t.Return, free)
+	}
+}